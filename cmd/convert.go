@@ -9,13 +9,24 @@ import (
 
 	"github.com/TomyTang331/Xml2ExcelByGo/internal/config"
 	"github.com/TomyTang331/Xml2ExcelByGo/internal/converter"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/parser"
 	"github.com/spf13/cobra"
 )
 
 var (
-	inputFile  string
-	outputFile string
-	bufferSize int
+	inputFile     string
+	outputFile    string
+	bufferSize    int
+	keepDimArrays bool
+	bitMap        bool
+	failOnError   bool
+	idMode        string
+	headerFile    string
+	includes      []string
+	excludes      []string
+	patchFile     string
+	layout        string
+	numericCells  bool
 )
 
 var convertCmd = &cobra.Command{
@@ -31,6 +42,16 @@ func init() {
 	convertCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input XML file path (required)")
 	convertCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output Excel file path (default: input_file.xlsx)")
 	convertCmd.Flags().IntVarP(&bufferSize, "buffer-size", "b", config.DefaultXMLBufferSize, "XML parser buffer size in bytes")
+	convertCmd.Flags().BoolVar(&bitMap, "bitmap", false, "Add a BitMap sheet drawing each SVD register's fields across its bits")
+	convertCmd.Flags().BoolVar(&failOnError, "fail-on-error", false, "Exit with an error when SVD layout validation reports errors")
+	convertCmd.Flags().StringVar(&headerFile, "header", "", "Also write a CMSIS-style C device header for an SVD file to this path")
+	convertCmd.Flags().StringVar(&idMode, "id-mode", string(parser.IDModeNumeric), "SVD row IDs: numeric, path (e.g. GPIOA.MODER.MODER5) or both")
+	convertCmd.Flags().StringVar(&layout, "layout", string(converter.LayoutFlat), "SVD sheet layout: flat or per-peripheral (an Index sheet and one sheet per peripheral)")
+	convertCmd.Flags().BoolVar(&numericCells, "numeric-cells", false, "Write raw SVD numbers (addresses, offsets, sizes, reset values, bit positions) as numeric cells shown in decimal; computed hex columns are unchanged")
+	convertCmd.Flags().StringVar(&patchFile, "patch", "", "Apply an svdtools-style YAML patch file to the SVD before converting")
+	convertCmd.Flags().StringSliceVar(&includes, "include", nil, "Only convert SVD peripherals matching these globs (name, groupName or PERIPH.REG path)")
+	convertCmd.Flags().StringSliceVar(&excludes, "exclude", nil, "Skip SVD peripherals or registers matching these globs")
+	convertCmd.Flags().BoolVar(&keepDimArrays, "keep-dim-arrays", false, "Keep SVD dim arrays as a single row instead of expanding each instance")
 
 	convertCmd.MarkFlagRequired("input")
 }
@@ -40,6 +61,24 @@ func runConvert(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("input file does not exist: %s", inputFile)
 	}
 
+	switch parser.IDMode(idMode) {
+	case parser.IDModeNumeric, parser.IDModePath, parser.IDModeBoth:
+	default:
+		return fmt.Errorf("invalid --id-mode %q: expected numeric, path or both", idMode)
+	}
+
+	switch converter.Layout(layout) {
+	case converter.LayoutFlat, converter.LayoutPerPeripheral:
+	default:
+		return fmt.Errorf("invalid --layout %q: expected flat or per-peripheral", layout)
+	}
+
+	for _, patterns := range [][]string{includes, excludes} {
+		if err := parser.CheckFilterPatterns(patterns); err != nil {
+			return err
+		}
+	}
+
 	if outputFile == "" {
 		ext := filepath.Ext(inputFile)
 		outputFile = strings.TrimSuffix(inputFile, ext) + ".xlsx"
@@ -51,7 +90,20 @@ func runConvert(cmd *cobra.Command, args []string) error {
 
 	if isSVDFormat(inputFile) {
 		fmt.Println("Detected CMSIS-SVD format, using multi-sheet converter...")
-		svdConv := converter.NewSVDConverter(bufferSize)
+		svdConv := converter.NewSVDConverter(bufferSize, converter.SVDOptions{
+			SVDOptions: parser.SVDOptions{
+				KeepDimArrays: keepDimArrays,
+				IDMode:        parser.IDMode(idMode),
+				PatchFile:     patchFile,
+				Include:       includes,
+				Exclude:       excludes,
+			},
+			BitMap:       bitMap,
+			FailOnError:  failOnError,
+			Layout:       converter.Layout(layout),
+			NumericCells: numericCells,
+			HeaderFile:   headerFile,
+		})
 		if err := svdConv.ConvertSVD(inputFile, outputFile); err != nil {
 			return fmt.Errorf("conversion failed: %w", err)
 		}
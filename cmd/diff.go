@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/config"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffOld        string
+	diffNew        string
+	diffOutput     string
+	diffBufferSize int
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare two SVD files and write the differences to Excel",
+	Long: `Parse two CMSIS-SVD files and write a workbook with Added, Removed and Changed sheets for
+peripherals, registers, fields and interrupts. Elements are matched by name path
+(e.g. GPIOA.MODER.MODER5), so inserted elements do not shift the comparison.`,
+	RunE: runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffOld, "old", "", "Old SVD file path (required)")
+	diffCmd.Flags().StringVar(&diffNew, "new", "", "New SVD file path (required)")
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "svd_diff.xlsx", "Output Excel file path")
+	diffCmd.Flags().IntVarP(&diffBufferSize, "buffer-size", "b", config.DefaultXMLBufferSize, "XML parser buffer size in bytes")
+
+	diffCmd.MarkFlagRequired("old")
+	diffCmd.MarkFlagRequired("new")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	for _, file := range []string{diffOld, diffNew} {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return fmt.Errorf("input file does not exist: %s", file)
+		}
+	}
+
+	fmt.Printf("Starting comparison...\n")
+	fmt.Printf("Old:    %s\n", diffOld)
+	fmt.Printf("New:    %s\n", diffNew)
+	fmt.Printf("Output: %s\n", diffOutput)
+
+	if err := converter.NewSVDDiffConverter(diffBufferSize).Diff(diffOld, diffNew, diffOutput); err != nil {
+		return fmt.Errorf("diff failed: %w", err)
+	}
+
+	fmt.Printf("✓ Diff completed successfully!\n")
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reverseInput  string
+	reverseOutput string
+)
+
+var reverseCmd = &cobra.Command{
+	Use:   "reverse",
+	Short: "Regenerate an SVD file from an SVD workbook",
+	Long: `Read a workbook produced by converting an SVD file, including any edits made to it, and write
+the device back as a CMSIS-SVD file. Rows are linked through their _id, _peripheral_id,
+_cluster_id, _register_id and _field_id columns; rows whose parent is missing are reported and skipped.`,
+	RunE: runReverse,
+}
+
+func init() {
+	rootCmd.AddCommand(reverseCmd)
+
+	reverseCmd.Flags().StringVarP(&reverseInput, "input", "i", "", "Input Excel workbook path (required)")
+	reverseCmd.Flags().StringVarP(&reverseOutput, "output", "o", "", "Output SVD file path (default: input_file.svd)")
+
+	reverseCmd.MarkFlagRequired("input")
+}
+
+func runReverse(cmd *cobra.Command, args []string) error {
+	if _, err := os.Stat(reverseInput); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", reverseInput)
+	}
+
+	if reverseOutput == "" {
+		ext := filepath.Ext(reverseInput)
+		reverseOutput = strings.TrimSuffix(reverseInput, ext) + ".svd"
+	}
+
+	fmt.Printf("Starting reverse conversion...\n")
+	fmt.Printf("Input:  %s\n", reverseInput)
+	fmt.Printf("Output: %s\n", reverseOutput)
+
+	if err := converter.NewSVDReverseConverter().ConvertWorkbook(reverseInput, reverseOutput); err != nil {
+		return fmt.Errorf("reverse conversion failed: %w", err)
+	}
+
+	fmt.Printf("✓ Reverse conversion completed successfully!\n")
+	return nil
+}
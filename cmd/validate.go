@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/config"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateInput      string
+	validateBufferSize int
+	validateJSON       bool
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a CMSIS-SVD file against the SVD schema rules",
+	Long: `Check a CMSIS-SVD file against the CMSIS-SVD 1.1/1.3 rules: required elements, legal values for
+access, modifiedWriteValues, readAction, usage and endian, number formats and resolvable derivedFrom
+references, followed by the register map layout checks of the convert command.
+Exits non-zero when any error is found.`,
+	RunE:          runValidate,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// validationReport is the --json output of the validate command.
+type validationReport struct {
+	File     string         `json:"file"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Issues   []parser.Issue `json:"issues"`
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringVarP(&validateInput, "input", "i", "", "Input SVD file path (required)")
+	validateCmd.Flags().IntVarP(&validateBufferSize, "buffer-size", "b", config.DefaultXMLBufferSize, "XML parser buffer size in bytes")
+	validateCmd.Flags().BoolVar(&validateJSON, "json", false, "Write the report as JSON")
+
+	validateCmd.MarkFlagRequired("input")
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if _, err := os.Stat(validateInput); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", validateInput)
+	}
+
+	issues, err := parser.NewSVDParser(validateBufferSize, parser.SVDOptions{}).Validate(validateInput)
+	if err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	report := validationReport{File: validateInput, Issues: issues}
+	for _, issue := range issues {
+		if issue.Severity == parser.SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	if report.Issues == nil {
+		report.Issues = []parser.Issue{}
+	}
+
+	if validateJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	} else {
+		printValidationReport(report)
+	}
+
+	if report.Errors > 0 {
+		return fmt.Errorf("%s: %d errors", validateInput, report.Errors)
+	}
+	return nil
+}
+
+// printValidationReport prints one line per issue followed by a summary.
+func printValidationReport(report validationReport) {
+	for _, issue := range report.Issues {
+		fmt.Printf("%-7s  %-16s  %s: %s\n", issue.Severity, issue.Check, issue.Path, issue.Message)
+	}
+	if len(report.Issues) > 0 {
+		fmt.Println()
+	}
+
+	if report.Errors == 0 && report.Warnings == 0 {
+		fmt.Printf("✓ %s is valid\n", report.File)
+		return
+	}
+	fmt.Printf("%s: %d errors, %d warnings\n", report.File, report.Errors, report.Warnings)
+}
@@ -14,4 +14,25 @@ const (
 	// Excel formatting
 	DefaultColWidth = 15
 	HeaderStyleBg   = "#E0E0E0"
+	BorderColor     = "#A6A6A6"
+	LinkFontColor   = "#0563C1"
+
+	// Register bit-map sheet: bit column width and fill colors by field access
+	BitMapColWidth    = 6
+	BitMapReadWriteBg = "#C6EFCE"
+	BitMapReadOnlyBg  = "#DDEBF7"
+	BitMapWriteOnlyBg = "#FCE4D6"
+	BitMapOtherBg     = "#FFF2CC"
+	BitMapReservedBg  = "#BFBFBF"
+	BitMapUnusedBg    = "#7F7F7F"
+
+	// SVD memory map sheet: fill of unmapped address range rows
+	MemoryMapGapBg = "#EDEDED"
+
+	// Per-peripheral SVD layout: fill of register rows above their fields
+	LayoutRegisterBg = "#DDEBF7"
+
+	// SVD diff workbook: fills of the old and new value of a changed attribute
+	DiffOldBg = "#FFC7CE"
+	DiffNewBg = "#C6EFCE"
 )
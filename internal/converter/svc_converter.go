@@ -7,114 +7,275 @@ import (
 	"github.com/TomyTang331/Xml2ExcelByGo/internal/config"
 	"github.com/TomyTang331/Xml2ExcelByGo/internal/parser"
 	"github.com/TomyTang331/Xml2ExcelByGo/internal/writer"
+	"github.com/xuri/excelize/v2"
 )
 
+// SVDOptions configures the SVD conversion.
+type SVDOptions struct {
+	parser.SVDOptions
+
+	// BitMap adds a sheet drawing each register's fields across its bits.
+	BitMap bool
+
+	// FailOnError makes the conversion fail when layout validation reports errors.
+	// The workbook, including its Issues sheet, is still written.
+	FailOnError bool
+
+	// Layout selects the flat sheets (default) or one sheet per peripheral.
+	Layout Layout
+
+	// NumericCells writes SVD number columns as numeric cells instead of text.
+	NumericCells bool
+
+	// HeaderFile, when set, is where a CMSIS-style C device header is written from
+	// the same parse as the workbook.
+	HeaderFile string
+}
+
 type SVDConverter struct {
 	bufferSize int
 	batchSize  int
+	options    SVDOptions
 }
 
-func NewSVDConverter(bufferSize int) *SVDConverter {
+func NewSVDConverter(bufferSize int, options SVDOptions) *SVDConverter {
 	return &SVDConverter{
 		bufferSize: bufferSize,
 		batchSize:  config.DefaultBatchSize,
+		options:    options,
 	}
 }
 
+// svdSheet describes one output sheet and the channel its rows arrive on.
+type svdSheet struct {
+	name          string
+	label         string
+	headers       []string
+	rows          <-chan map[string]string
+	progressEvery int
+	keep          bool
+	// omit drains (and keeps) the rows without creating the sheet
+	omit bool
+	// numeric lists the columns written as numeric cells
+	numeric []string
+}
+
 func (c *SVDConverter) ConvertSVD(inputFile, outputFile string) error {
-	p := parser.NewSVDParser(c.bufferSize)
-	peripheralChan, registerChan, fieldChan, errChan := p.ParseSVD(inputFile)
+	p := parser.NewSVDParser(c.bufferSize, c.options.SVDOptions)
+	streams := p.ParseSVD(inputFile)
 
-	peripheralHeaders := []string{
-		"_id", "name", "description", "groupName", "baseAddress",
-		"size", "access", "resetValue",
+	sheets := []svdSheet{
+		{
+			name:          "Device",
+			label:         "device properties",
+			headers:       []string{"property", "value"},
+			rows:          streams.Device,
+			progressEvery: 100,
+			keep:          true,
+		},
+		{
+			name:  "Peripherals",
+			label: "peripherals",
+			headers: []string{
+				"_id", "name", "description", "groupName", "alternatePeripheral", "headerStructName", "baseAddress",
+				"_start_address", "_end_address",
+				"size", "access", "resetValue", "derivedFrom",
+				"dim", "dimIncrement", "dimIndex", "_dim_array", "_dim_index",
+			},
+			rows:          streams.Peripherals,
+			progressEvery: 100,
+			keep:          true,
+		},
+		{
+			name:  "Clusters",
+			label: "clusters",
+			headers: []string{
+				"_id", "_peripheral_id", "_peripheral_name",
+				"_parent_cluster_id", "_parent_cluster_name", "_cluster_path",
+				"name", "description", "headerStructName", "alternateCluster",
+				"addressOffset", "_peripheral_offset", "_address", "size", "access", "resetValue", "derivedFrom",
+				"dim", "dimIncrement", "dimIndex", "_dim_array", "_dim_index",
+			},
+			rows:          streams.Clusters,
+			progressEvery: 100,
+			keep:          c.options.HeaderFile != "",
+		},
+		{
+			name:  "Registers",
+			label: "registers",
+			headers: []string{
+				"_id", "_peripheral_id", "_peripheral_name", "_cluster_id", "_cluster_path", "_fields",
+				"name", "displayName", "description", "alternateGroup", "alternateRegister", "dataType",
+				"addressOffset", "_peripheral_offset", "_address", "size", "access", "resetValue", "derivedFrom",
+				"modifiedWriteValues", "readAction", "writeConstraint", "_behavior",
+				"dim", "dimIncrement", "dimIndex", "_dim_array", "_dim_index",
+				"_effective_size", "_size_source", "_effective_access", "_access_source",
+				"_effective_protection", "_protection_source",
+				"_effective_reset_value", "_reset_value_source",
+				"_effective_reset_mask", "_reset_mask_source",
+			},
+			rows:          streams.Registers,
+			progressEvery: 1000,
+			keep:          c.options.BitMap || c.options.HeaderFile != "",
+		},
+		{
+			name:  "Fields",
+			label: "fields",
+			headers: []string{
+				"_id", "_register_id", "_register_name", "_peripheral_id", "_peripheral_name",
+				"name", "description", "bitOffset", "bitWidth", "lsb", "msb", "_bit_range", "_mask",
+				"_bit_notation", "_bit_conflict", "access", "derivedFrom",
+				"modifiedWriteValues", "readAction", "writeConstraint", "_behavior",
+				"dim", "dimIncrement", "dimIndex", "_dim_array", "_dim_index",
+				"_effective_access", "_access_source",
+				"_reset_value", "_reset_value_dec", "_reset_defined", "_reset_enum",
+			},
+			rows:          streams.Fields,
+			progressEvery: 1000,
+			keep:          c.options.BitMap || c.options.HeaderFile != "",
+		},
+		{
+			name:  "Interrupts",
+			label: "interrupts",
+			headers: []string{
+				"_id", "_peripheral_id", "_peripheral_name",
+				"name", "value", "description",
+			},
+			rows:          streams.Interrupts,
+			progressEvery: 100,
+			keep:          c.options.HeaderFile != "",
+		},
+		{
+			name:  "EnumeratedValues",
+			label: "enumerated values",
+			headers: []string{
+				"_id", "_field_id", "_field_name", "_register_id", "_register_name",
+				"_peripheral_id", "_peripheral_name",
+				"enumeratedValuesName", "usage", "derivedFrom",
+				"name", "value", "description", "isDefault",
+			},
+			rows:          streams.Enums,
+			progressEvery: 1000,
+		},
+		{
+			name:  "AddressBlocks",
+			label: "address blocks",
+			headers: []string{
+				"_id", "_peripheral_id", "_peripheral_name",
+				"offset", "size", "usage", "protection",
+				"_start_address", "_end_address",
+			},
+			rows:          streams.Blocks,
+			progressEvery: 100,
+		},
+		{
+			name:          "Issues",
+			label:         "issues",
+			headers:       []string{"severity", "check", "path", "message"},
+			rows:          streams.Issues,
+			progressEvery: 1000,
+			keep:          true,
+		},
 	}
 
-	registerHeaders := []string{
-		"_id", "_peripheral_id", "_peripheral_name",
-		"name", "displayName", "description",
-		"addressOffset", "size", "access", "resetValue",
+	if c.options.IDMode == parser.IDModeBoth {
+		for i := range sheets {
+			sheets[i].headers = withPathColumn(sheets[i].headers)
+		}
 	}
 
-	fieldHeaders := []string{
-		"_id", "_register_id", "_register_name", "_peripheral_id", "_peripheral_name",
-		"name", "description", "bitOffset", "bitWidth", "access",
+	perPeripheral := c.options.Layout == LayoutPerPeripheral
+	if perPeripheral {
+		for i := range sheets {
+			if flatLayoutSheets[sheets[i].name] {
+				sheets[i].omit = true
+				sheets[i].keep = true
+			}
+		}
 	}
 
 	excelWriter := writer.NewExcelWriter(outputFile, c.batchSize)
 	defer excelWriter.Close()
 
-	if err := excelWriter.CreateSheet("Peripherals", peripheralHeaders); err != nil {
-		return fmt.Errorf("failed to create Peripherals sheet: %w", err)
+	var sheetNames []string
+	for _, sheet := range sheets {
+		if sheet.omit {
+			continue
+		}
+		if err := excelWriter.CreateSheet(sheet.name, sheet.headers); err != nil {
+			return fmt.Errorf("failed to create %s sheet: %w", sheet.name, err)
+		}
+		sheetNames = append(sheetNames, sheet.name)
 	}
 
-	if err := excelWriter.CreateSheet("Registers", registerHeaders); err != nil {
-		return fmt.Errorf("failed to create Registers sheet: %w", err)
+	// The Index sheet is created up front so the other sheets can link to it
+	if perPeripheral {
+		if err := excelWriter.CreateSheet(indexSheetName, indexHeaders); err != nil {
+			return fmt.Errorf("failed to create %s sheet: %w", indexSheetName, err)
+		}
+		sheetNames = append(sheetNames, indexSheetName, memoryMapSheetName, bitMapSheetName)
 	}
 
-	if err := excelWriter.CreateSheet("Fields", fieldHeaders); err != nil {
-		return fmt.Errorf("failed to create Fields sheet: %w", err)
+	if err := setSVDLinks(excelWriter, sheets, perPeripheral); err != nil {
+		return err
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(3)
+	numberStyle := 0
+	if c.options.NumericCells {
+		var err error
+		if numberStyle, err = excelWriter.NewNumberStyle(); err != nil {
+			return fmt.Errorf("failed to create number style: %w", err)
+		}
+		for i := range sheets {
+			sheets[i].numeric = numericColumns(sheets[i])
+		}
+	}
 
-	errors := make(chan error, 3)
+	var wg sync.WaitGroup
+	wg.Add(len(sheets) + 1)
 
-	// Process peripherals
-	go func() {
-		defer wg.Done()
-		count := 0
-		for data := range peripheralChan {
-			if err := excelWriter.WriteRow("Peripherals", data); err != nil {
-				errors <- fmt.Errorf("failed to write peripheral: %w", err)
-				return
-			}
-			count++
-			if count%100 == 0 {
-				fmt.Printf("  Processed %d peripherals...\n", count)
-			}
-		}
-		fmt.Printf("✓ Peripherals: %d rows\n", count)
-	}()
+	errors := make(chan error, len(sheets)+1)
 
-	// Process registers
-	go func() {
-		defer wg.Done()
-		count := 0
-		for data := range registerChan {
-			if err := excelWriter.WriteRow("Registers", data); err != nil {
-				errors <- fmt.Errorf("failed to write register: %w", err)
-				return
-			}
-			count++
-			if count%1000 == 0 {
-				fmt.Printf("  Processed %d registers...\n", count)
-			}
-		}
-		fmt.Printf("✓ Registers: %d rows\n", count)
-	}()
+	// kept holds the rows of sheets whose data is reused after streaming, one slot per sheet
+	kept := make([][]map[string]string, len(sheets))
 
-	// Process fields
-	go func() {
-		defer wg.Done()
-		count := 0
-		for data := range fieldChan {
-			if err := excelWriter.WriteRow("Fields", data); err != nil {
-				errors <- fmt.Errorf("failed to write field: %w", err)
-				return
+	for i, sheet := range sheets {
+		go func(i int, sheet svdSheet) {
+			defer wg.Done()
+			count := 0
+			for data := range sheet.rows {
+				if sheet.omit {
+					kept[i] = append(kept[i], data)
+					continue
+				}
+				var err error
+				if len(sheet.numeric) > 0 {
+					err = excelWriter.WriteTypedRow(sheet.name, data, numberCells(data, sheet.numeric, numberStyle))
+				} else {
+					err = excelWriter.WriteRow(sheet.name, data)
+				}
+				if err != nil {
+					errors <- fmt.Errorf("failed to write %s row: %w", sheet.label, err)
+					return
+				}
+				if sheet.keep {
+					kept[i] = append(kept[i], data)
+				}
+				count++
+				if count%sheet.progressEvery == 0 {
+					fmt.Printf("  Processed %d %s...\n", count, sheet.label)
+				}
 			}
-			count++
-			if count%1000 == 0 {
-				fmt.Printf("  Processed %d fields...\n", count)
+			if !sheet.omit {
+				fmt.Printf("✓ %s: %d rows\n", sheet.name, count)
 			}
-		}
-		fmt.Printf("✓ Fields: %d rows\n", count)
-	}()
+		}(i, sheet)
+	}
 
 	// Check for parsing errors
 	go func() {
-		for err := range errChan {
+		defer wg.Done()
+		for err := range streams.Errors {
 			if err != nil {
 				errors <- err
 			}
@@ -131,6 +292,140 @@ func (c *SVDConverter) ConvertSVD(inputFile, outputFile string) error {
 		}
 	}
 
+	if err := writeMemoryMapSheet(excelWriter, keptRows(sheets, kept, "Device"), keptRows(sheets, kept, "Peripherals")); err != nil {
+		return fmt.Errorf("failed to write MemoryMap sheet: %w", err)
+	}
+
+	if c.options.BitMap {
+		if err := writeBitMapSheet(excelWriter, keptRows(sheets, kept, "Registers"), keptRows(sheets, kept, "Fields")); err != nil {
+			return fmt.Errorf("failed to write BitMap sheet: %w", err)
+		}
+	}
+
+	// Peripheral sheets go last so the summary sheets stay next to the Index
+	if perPeripheral {
+		err := writePeripheralSheets(excelWriter, sheetNames, keptRows(sheets, kept, "Peripherals"),
+			keptRows(sheets, kept, "Registers"), keptRows(sheets, kept, "Fields"))
+		if err != nil {
+			return fmt.Errorf("failed to write peripheral sheets: %w", err)
+		}
+	}
+
+	if c.options.HeaderFile != "" {
+		model := headerModel(keptRows(sheets, kept, "Device"), keptRows(sheets, kept, "Peripherals"),
+			keptRows(sheets, kept, "Clusters"), keptRows(sheets, kept, "Registers"),
+			keptRows(sheets, kept, "Fields"), keptRows(sheets, kept, "Interrupts"))
+		if err := writer.WriteCHeader(c.options.HeaderFile, model); err != nil {
+			return fmt.Errorf("failed to write C header: %w", err)
+		}
+		fmt.Printf("✓ C header written to %s\n", c.options.HeaderFile)
+	}
+
 	fmt.Println("\nSaving file...")
+
+	if c.options.FailOnError {
+		if errorCount := countIssues(keptRows(sheets, kept, "Issues"), parser.SeverityError); errorCount > 0 {
+			return fmt.Errorf("layout validation found %d errors, see the Issues sheet", errorCount)
+		}
+	}
+	return nil
+}
+
+// countIssues returns the number of issue rows with the given severity.
+func countIssues(issues []map[string]string, severity string) int {
+	count := 0
+	for _, issue := range issues {
+		if issue["severity"] == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// setSVDLinks turns the _peripheral_id and _register_id columns into hyperlinks to the
+// referenced Peripherals and Registers rows, and the Registers _fields column into a
+// link to the register's first field. In the per-peripheral layout peripherals link
+// to their Index row and register IDs are left plain.
+func setSVDLinks(excelWriter *writer.ExcelWriter, sheets []svdSheet, perPeripheral bool) error {
+	for _, sheet := range sheets {
+		if sheet.omit {
+			continue
+		}
+		links := make(map[string]writer.ColumnLink)
+		for _, header := range sheet.headers {
+			switch header {
+			case "_peripheral_id":
+				if perPeripheral {
+					links[header] = writer.ColumnLink{Sheet: indexSheetName, IndexKey: "_peripheral_index"}
+				} else {
+					links[header] = writer.ColumnLink{Sheet: "Peripherals", IndexKey: "_peripheral_index"}
+				}
+			case "_register_id":
+				if !perPeripheral {
+					links[header] = writer.ColumnLink{Sheet: "Registers", IndexKey: "_register_index"}
+				}
+			case "_fields":
+				links[header] = writer.ColumnLink{Sheet: "Fields", IndexKey: "_first_field_index", Label: "fields"}
+			}
+		}
+		if len(links) == 0 {
+			continue
+		}
+		if err := excelWriter.SetColumnLinks(sheet.name, links); err != nil {
+			return fmt.Errorf("failed to link %s sheet: %w", sheet.name, err)
+		}
+	}
+	return nil
+}
+
+// maxExactNumber is the largest integer Excel's double precision numbers hold exactly.
+const maxExactNumber = 1 << 53
+
+// numericColumns returns the scaledNonNegativeInteger columns of an element sheet,
+// and the interrupt numbers, which --numeric-cells writes as numbers.
+func numericColumns(sheet svdSheet) []string {
+	var numeric []string
+	for _, header := range sheet.headers {
+		if parser.IsNumericElement(header) || (sheet.name == "Interrupts" && header == "value") {
+			numeric = append(numeric, header)
+		}
+	}
+	return numeric
+}
+
+// numberCells converts the SVD numbers of the given columns to numeric cells. Values
+// that do not parse, or are too large for Excel to hold exactly, stay text.
+func numberCells(data map[string]string, columns []string, styleID int) map[string]interface{} {
+	cells := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		value, err := parser.ParseSVDInt(data[column])
+		if err != nil || value > maxExactNumber {
+			continue
+		}
+		cells[column] = excelize.Cell{Value: value, StyleID: styleID}
+	}
+	return cells
+}
+
+// withPathColumn inserts the _path column after the _id column of a sheet's headers.
+func withPathColumn(headers []string) []string {
+	for i, header := range headers {
+		if header == "_id" {
+			result := make([]string, 0, len(headers)+1)
+			result = append(result, headers[:i+1]...)
+			result = append(result, "_path")
+			return append(result, headers[i+1:]...)
+		}
+	}
+	return headers
+}
+
+// keptRows returns the rows kept for the named sheet.
+func keptRows(sheets []svdSheet, kept [][]map[string]string, name string) []map[string]string {
+	for i, sheet := range sheets {
+		if sheet.name == name {
+			return kept[i]
+		}
+	}
 	return nil
 }
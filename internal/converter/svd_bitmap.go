@@ -0,0 +1,183 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/config"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/parser"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/writer"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	bitMapSheetName = "BitMap"
+
+	// bitMapLabelColumns are the register and address columns preceding the bit columns.
+	bitMapLabelColumns = 2
+)
+
+// bitMapStyles holds the cell styles of the bit-map sheet.
+type bitMapStyles struct {
+	byAccess map[string]int
+	other    int
+	reserved int
+	unused   int
+}
+
+// writeBitMapSheet adds a sheet with one row per register and one column per bit,
+// where each field's name spans its bits as a merged cell colored by access type.
+func writeBitMapSheet(ew *writer.ExcelWriter, registers, fields []map[string]string) error {
+	fieldsByRegister := make(map[string][]map[string]string)
+	for _, field := range fields {
+		fieldsByRegister[field["_register_id"]] = append(fieldsByRegister[field["_register_id"]], field)
+	}
+
+	maxBits := 0
+	for _, register := range registers {
+		if size := registerBits(register); size > maxBits {
+			maxBits = size
+		}
+	}
+	if maxBits == 0 {
+		return nil
+	}
+
+	headers := []string{"register", "address"}
+	widths := make(map[int]float64, maxBits)
+	for bit := maxBits - 1; bit >= 0; bit-- {
+		headers = append(headers, strconv.Itoa(bit))
+		widths[len(headers)] = config.BitMapColWidth
+	}
+
+	if err := ew.CreateSheet(bitMapSheetName, headers); err != nil {
+		return err
+	}
+	if err := ew.SetColumnWidths(bitMapSheetName, widths); err != nil {
+		return err
+	}
+
+	styles, err := newBitMapStyles(ew)
+	if err != nil {
+		return err
+	}
+
+	for _, register := range registers {
+		cells, merges := bitMapRow(register, fieldsByRegister[register["_id"]], maxBits, styles)
+		if err := ew.WriteCells(bitMapSheetName, cells, merges); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✓ %s: %d rows\n", bitMapSheetName, len(registers))
+	return nil
+}
+
+func newBitMapStyles(ew *writer.ExcelWriter) (*bitMapStyles, error) {
+	styles := &bitMapStyles{byAccess: make(map[string]int)}
+
+	colors := map[string]string{
+		"read-write": config.BitMapReadWriteBg,
+		"read-only":  config.BitMapReadOnlyBg,
+		"write-only": config.BitMapWriteOnlyBg,
+	}
+	for access, color := range colors {
+		styleID, err := ew.NewFillStyle(color)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create style: %w", err)
+		}
+		styles.byAccess[access] = styleID
+	}
+
+	var err error
+	if styles.other, err = ew.NewFillStyle(config.BitMapOtherBg); err != nil {
+		return nil, fmt.Errorf("failed to create style: %w", err)
+	}
+	if styles.reserved, err = ew.NewFillStyle(config.BitMapReservedBg); err != nil {
+		return nil, fmt.Errorf("failed to create style: %w", err)
+	}
+	if styles.unused, err = ew.NewFillStyle(config.BitMapUnusedBg); err != nil {
+		return nil, fmt.Errorf("failed to create style: %w", err)
+	}
+
+	return styles, nil
+}
+
+// bitMapRow lays out one register. Bits at or above the register size are marked
+// unused, bits no field covers are marked reserved, and a field overlapping bits
+// already taken by an earlier field is left out.
+func bitMapRow(register map[string]string, fields []map[string]string, maxBits int, styles *bitMapStyles) ([]interface{}, [][2]int) {
+	size := registerBits(register)
+
+	cells := make([]interface{}, bitMapLabelColumns+maxBits)
+	cells[0] = registerLabel(register)
+	cells[1] = register["_address"]
+
+	// column returns the 1-based sheet column of a bit
+	column := func(bit int) int {
+		return bitMapLabelColumns + maxBits - bit
+	}
+
+	taken := make([]bool, maxBits)
+	var merges [][2]int
+	for _, field := range fields {
+		lsb, lsbErr := strconv.Atoi(field["lsb"])
+		msb, msbErr := strconv.Atoi(field["msb"])
+		if lsbErr != nil || msbErr != nil || msb >= size || lsb < 0 {
+			continue
+		}
+
+		overlaps := false
+		for bit := lsb; bit <= msb; bit++ {
+			overlaps = overlaps || taken[bit]
+		}
+		if overlaps {
+			continue
+		}
+
+		style, ok := styles.byAccess[field["_effective_access"]]
+		if !ok {
+			style = styles.other
+		}
+		for bit := lsb; bit <= msb; bit++ {
+			taken[bit] = true
+			cells[column(bit)-1] = excelize.Cell{StyleID: style}
+		}
+		cells[column(msb)-1] = excelize.Cell{Value: field["name"], StyleID: style}
+		if msb > lsb {
+			merges = append(merges, [2]int{column(msb), column(lsb)})
+		}
+	}
+
+	for bit := 0; bit < maxBits; bit++ {
+		if taken[bit] {
+			continue
+		}
+		style := styles.reserved
+		if bit >= size {
+			style = styles.unused
+		}
+		cells[column(bit)-1] = excelize.Cell{StyleID: style}
+	}
+
+	return cells, merges
+}
+
+// registerBits returns the effective size of a register row in bits.
+func registerBits(register map[string]string) int {
+	size, err := parser.ParseSVDInt(register["_effective_size"])
+	if err != nil || size == 0 || size > math.MaxUint32 {
+		return 0
+	}
+	return int(size)
+}
+
+// registerLabel names a register by its peripheral and cluster path, e.g. "DMAC.CH0.CFG".
+func registerLabel(register map[string]string) string {
+	label := register["_peripheral_name"] + "."
+	if path := register["_cluster_path"]; path != "" {
+		label += path + "."
+	}
+	return label + register["name"]
+}
@@ -0,0 +1,277 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/config"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/parser"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/writer"
+	"github.com/xuri/excelize/v2"
+)
+
+// diffAttribute is a compared column and the label it is reported under.
+type diffAttribute struct {
+	label  string
+	column string
+}
+
+// diffKind describes how one kind of element is matched and compared.
+type diffKind struct {
+	name       string
+	location   string
+	attributes []diffAttribute
+}
+
+// svdDiffKinds lists the compared element kinds, parents before children.
+var svdDiffKinds = []diffKind{
+	{
+		name:     "peripheral",
+		location: "baseAddress",
+		attributes: []diffAttribute{
+			{"base address", "baseAddress"},
+			{"end address", "_end_address"},
+			{"group", "groupName"},
+			{"derived from", "derivedFrom"},
+			{"description", "description"},
+		},
+	},
+	{
+		name:     "register",
+		location: "_address",
+		attributes: []diffAttribute{
+			{"offset", "_peripheral_offset"},
+			{"size", "_effective_size"},
+			{"access", "_effective_access"},
+			{"reset value", "_effective_reset_value"},
+			{"reset mask", "_effective_reset_mask"},
+			{"behavior", "_behavior"},
+			{"display name", "displayName"},
+			{"description", "description"},
+		},
+	},
+	{
+		name:     "field",
+		location: "_bit_range",
+		attributes: []diffAttribute{
+			{"bit offset", "bitOffset"},
+			{"bit width", "bitWidth"},
+			{"access", "_effective_access"},
+			{"reset value", "_reset_value"},
+			{"behavior", "_behavior"},
+			{"description", "description"},
+		},
+	},
+	{
+		name:     "interrupt",
+		location: "value",
+		attributes: []diffAttribute{
+			{"number", "value"},
+			{"description", "description"},
+		},
+	},
+}
+
+// SVDDiffConverter compares two SVD files and writes the differences to a workbook.
+type SVDDiffConverter struct {
+	bufferSize int
+	batchSize  int
+}
+
+func NewSVDDiffConverter(bufferSize int) *SVDDiffConverter {
+	return &SVDDiffConverter{
+		bufferSize: bufferSize,
+		batchSize:  config.DefaultBatchSize,
+	}
+}
+
+// svdElements holds the rows of each element kind in document order.
+type svdElements map[string][]map[string]string
+
+// Diff parses both files and writes Added, Removed and Changed sheets. Elements are
+// matched by their name path (e.g. GPIOA.MODER.MODER5); elements inside an added or
+// removed peripheral or register are not listed separately.
+func (c *SVDDiffConverter) Diff(oldFile, newFile, outputFile string) error {
+	oldElements, err := c.collect(oldFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", oldFile, err)
+	}
+	newElements, err := c.collect(newFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", newFile, err)
+	}
+
+	excelWriter := writer.NewExcelWriter(outputFile, c.batchSize)
+	defer excelWriter.Close()
+
+	listHeaders := []string{"kind", "path", "location", "description"}
+	for _, sheet := range []string{"Added", "Removed"} {
+		if err := excelWriter.CreateSheet(sheet, listHeaders); err != nil {
+			return fmt.Errorf("failed to create %s sheet: %w", sheet, err)
+		}
+	}
+	if err := excelWriter.CreateSheet("Changed", []string{"kind", "path", "attribute", "old", "new"}); err != nil {
+		return fmt.Errorf("failed to create Changed sheet: %w", err)
+	}
+
+	oldStyle, err := excelWriter.NewHighlightStyle(config.DiffOldBg)
+	if err != nil {
+		return fmt.Errorf("failed to create style: %w", err)
+	}
+	newStyle, err := excelWriter.NewHighlightStyle(config.DiffNewBg)
+	if err != nil {
+		return fmt.Errorf("failed to create style: %w", err)
+	}
+
+	added, removed, changed := 0, 0, 0
+	addedPaths := make(map[string]bool)
+	removedPaths := make(map[string]bool)
+
+	for _, kind := range svdDiffKinds {
+		oldByPath := indexByPath(oldElements[kind.name])
+		newByPath := indexByPath(newElements[kind.name])
+
+		for _, row := range newElements[kind.name] {
+			path := row["_path"]
+			oldRow, ok := oldByPath[path]
+			if !ok {
+				addedPaths[path] = true
+				if kind.name == "interrupt" || !hasAncestor(addedPaths, path) {
+					if err := excelWriter.WriteRow("Added", diffListRow(kind, row)); err != nil {
+						return fmt.Errorf("failed to write Added row: %w", err)
+					}
+					added++
+				}
+				continue
+			}
+
+			for _, attribute := range kind.attributes {
+				oldValue, newValue := oldRow[attribute.column], row[attribute.column]
+				if equalSVDValues(oldValue, newValue) {
+					continue
+				}
+				cells := []interface{}{
+					kind.name, path, attribute.label,
+					excelize.Cell{Value: oldValue, StyleID: oldStyle},
+					excelize.Cell{Value: newValue, StyleID: newStyle},
+				}
+				if err := excelWriter.WriteCells("Changed", cells, nil); err != nil {
+					return fmt.Errorf("failed to write Changed row: %w", err)
+				}
+				changed++
+			}
+		}
+
+		for _, row := range oldElements[kind.name] {
+			path := row["_path"]
+			if _, ok := newByPath[path]; ok {
+				continue
+			}
+			removedPaths[path] = true
+			if kind.name == "interrupt" || !hasAncestor(removedPaths, path) {
+				if err := excelWriter.WriteRow("Removed", diffListRow(kind, row)); err != nil {
+					return fmt.Errorf("failed to write Removed row: %w", err)
+				}
+				removed++
+			}
+		}
+	}
+
+	fmt.Printf("✓ Diff: %d added, %d removed, %d changed attributes\n", added, removed, changed)
+	fmt.Println("\nSaving file...")
+	return nil
+}
+
+// collect parses an SVD file and keeps the peripheral, register, field and interrupt rows.
+func (c *SVDDiffConverter) collect(filename string) (svdElements, error) {
+	p := parser.NewSVDParser(c.bufferSize, parser.SVDOptions{})
+	streams := p.ParseSVD(filename)
+
+	elements := make(svdElements)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	collected := map[string]<-chan map[string]string{
+		"peripheral": streams.Peripherals,
+		"register":   streams.Registers,
+		"field":      streams.Fields,
+		"interrupt":  streams.Interrupts,
+	}
+	for kind, rows := range collected {
+		wg.Add(1)
+		go func(kind string, rows <-chan map[string]string) {
+			defer wg.Done()
+			var kept []map[string]string
+			for row := range rows {
+				kept = append(kept, row)
+			}
+			mu.Lock()
+			elements[kind] = kept
+			mu.Unlock()
+		}(kind, rows)
+	}
+
+	// The remaining streams are drained so the parser never blocks on them
+	for _, rows := range []<-chan map[string]string{streams.Device, streams.Clusters, streams.Enums, streams.Blocks, streams.Issues} {
+		wg.Add(1)
+		go func(rows <-chan map[string]string) {
+			defer wg.Done()
+			for range rows {
+			}
+		}(rows)
+	}
+
+	var parseErr error
+	for err := range streams.Errors {
+		if err != nil && parseErr == nil {
+			parseErr = err
+		}
+	}
+	wg.Wait()
+
+	return elements, parseErr
+}
+
+// indexByPath maps each row's name path to the row.
+func indexByPath(rows []map[string]string) map[string]map[string]string {
+	index := make(map[string]map[string]string, len(rows))
+	for _, row := range rows {
+		index[row["_path"]] = row
+	}
+	return index
+}
+
+// hasAncestor reports whether one of the dotted parents of path is in paths.
+func hasAncestor(paths map[string]bool, path string) bool {
+	for i := strings.LastIndex(path, "."); i > 0; i = strings.LastIndex(path[:i], ".") {
+		if paths[path[:i]] {
+			return true
+		}
+	}
+	return false
+}
+
+// diffListRow builds an Added or Removed sheet row.
+func diffListRow(kind diffKind, row map[string]string) map[string]string {
+	return map[string]string{
+		"kind":        kind.name,
+		"path":        row["_path"],
+		"location":    row[kind.location],
+		"description": row["description"],
+	}
+}
+
+// equalSVDValues compares two values, numerically when both are SVD numbers and
+// otherwise ignoring differences in whitespace.
+func equalSVDValues(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if x, err := parser.ParseSVDInt(a); err == nil {
+		if y, err := parser.ParseSVDInt(b); err == nil {
+			return x == y
+		}
+	}
+	return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/parser"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/writer"
+)
+
+// headerModel builds the C header model from the kept SVD rows, parsing the SVD
+// numbers the header needs. Peripherals without a parsable baseAddress get no
+// declaration, fields without a parsable position are skipped and registers of
+// unknown size are treated as 32-bit.
+func headerModel(device, peripherals, clusters, registers, fields, interrupts []map[string]string) writer.CHeaderModel {
+	model := writer.CHeaderModel{Device: make(map[string]string)}
+	for _, row := range device {
+		model.Device[row["property"]] = row["value"]
+	}
+
+	for _, row := range peripherals {
+		peripheral := writer.HeaderPeripheral{
+			ID:               row["_id"],
+			Name:             row["name"],
+			Description:      row["description"],
+			HeaderStructName: row["headerStructName"],
+			DerivedFrom:      row["derivedFrom"],
+			DimArray:         row["_dim_array"],
+		}
+		if address, err := parser.ParseSVDInt(row["baseAddress"]); err == nil {
+			peripheral.BaseAddress = address
+			peripheral.HasBaseAddress = true
+		}
+		model.Peripherals = append(model.Peripherals, peripheral)
+	}
+
+	for _, row := range clusters {
+		cluster := headerElement(row)
+		cluster.ClusterID = row["_parent_cluster_id"]
+		model.Clusters = append(model.Clusters, cluster)
+	}
+
+	for _, row := range registers {
+		register := headerElement(row)
+		register.ClusterID = row["_cluster_id"]
+		register.Access = row["_effective_access"]
+		if size, err := parser.ParseSVDInt(row["_effective_size"]); err == nil && size > 0 {
+			register.Size = size
+		} else {
+			register.Size = 32
+		}
+		model.Registers = append(model.Registers, register)
+	}
+
+	for _, row := range fields {
+		offset, err := parser.ParseSVDInt(row["bitOffset"])
+		if err != nil {
+			continue
+		}
+		width, err := parser.ParseSVDInt(row["bitWidth"])
+		if err != nil || width == 0 {
+			continue
+		}
+		model.Fields = append(model.Fields, writer.HeaderField{
+			RegisterID: row["_register_id"],
+			Name:       row["name"],
+			Offset:     offset,
+			Width:      width,
+		})
+	}
+
+	for _, row := range interrupts {
+		value, err := parser.ParseInterruptValue(row["value"])
+		if err != nil {
+			continue
+		}
+		model.Interrupts = append(model.Interrupts, writer.HeaderInterrupt{
+			Name:        row["name"],
+			Description: row["description"],
+			Value:       value,
+		})
+	}
+	return model
+}
+
+// headerElement fills the fields registers and clusters share.
+func headerElement(row map[string]string) writer.HeaderElement {
+	element := writer.HeaderElement{
+		ID:               row["_id"],
+		PeripheralID:     row["_peripheral_id"],
+		Name:             row["name"],
+		Description:      row["description"],
+		HeaderStructName: row["headerStructName"],
+		DimArray:         row["_dim_array"],
+	}
+	element.Offset, _ = parser.ParseSVDInt(row["addressOffset"])
+	if dim, err := parser.ParseSVDInt(row["dim"]); err == nil {
+		element.Dim = dim
+		element.DimIncrement, _ = parser.ParseSVDInt(row["dimIncrement"])
+	}
+	return element
+}
@@ -0,0 +1,171 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/config"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/writer"
+	"github.com/xuri/excelize/v2"
+)
+
+// Layout selects how SVD peripherals, registers and fields are laid out in the workbook.
+type Layout string
+
+const (
+	// LayoutFlat writes the Peripherals, Clusters, Registers and Fields sheets.
+	LayoutFlat Layout = "flat"
+	// LayoutPerPeripheral replaces them with an Index sheet and one sheet per peripheral
+	// listing its registers with their fields beneath them.
+	LayoutPerPeripheral Layout = "per-peripheral"
+)
+
+const (
+	indexSheetName = "Index"
+
+	// maxSheetNameLength is Excel's limit on worksheet names.
+	maxSheetNameLength = 31
+)
+
+var indexHeaders = []string{
+	"_id", "sheet", "name", "groupName", "baseAddress", "_end_address", "derivedFrom", "_registers", "description",
+}
+
+var peripheralSheetHeaders = []string{
+	"register", "field", "address", "offset", "bits", "size", "access", "resetValue", "behavior", "description",
+}
+
+// flatLayoutSheets are the sheets the per-peripheral layout replaces.
+var flatLayoutSheets = map[string]bool{
+	"Peripherals": true,
+	"Clusters":    true,
+	"Registers":   true,
+	"Fields":      true,
+}
+
+// sheetNamer turns peripheral names into unique, valid Excel sheet names.
+type sheetNamer struct {
+	taken map[string]bool // lower-cased, Excel names are case-insensitive
+}
+
+func newSheetNamer(reserved []string) *sheetNamer {
+	n := &sheetNamer{taken: map[string]bool{"history": true}}
+	for _, name := range reserved {
+		n.taken[strings.ToLower(name)] = true
+	}
+	return n
+}
+
+// name replaces the characters Excel rejects (: \ / ? * [ ]) and leading or trailing
+// apostrophes, truncates to 31 characters and appends ~2, ~3, ... to repeated names.
+func (n *sheetNamer) name(peripheral string) string {
+	base := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(peripheral))
+	base = strings.Trim(base, "'")
+	if base == "" {
+		base = "Peripheral"
+	}
+	base = truncateRunes(base, maxSheetNameLength)
+
+	name := base
+	for i := 2; n.taken[strings.ToLower(name)]; i++ {
+		suffix := "~" + strconv.Itoa(i)
+		name = truncateRunes(base, maxSheetNameLength-len(suffix)) + suffix
+	}
+	n.taken[strings.ToLower(name)] = true
+	return name
+}
+
+func truncateRunes(s string, length int) string {
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	return string([]rune(s)[:length])
+}
+
+// writePeripheralSheets writes one sheet per peripheral and fills the Index sheet,
+// which must already be created, with a link to each of them.
+func writePeripheralSheets(ew *writer.ExcelWriter, reserved []string, peripherals, registers, fields []map[string]string) error {
+	registersByPeripheral := make(map[string][]map[string]string)
+	for _, register := range registers {
+		registersByPeripheral[register["_peripheral_id"]] = append(registersByPeripheral[register["_peripheral_id"]], register)
+	}
+	fieldsByRegister := make(map[string][]map[string]string)
+	for _, field := range fields {
+		fieldsByRegister[field["_register_id"]] = append(fieldsByRegister[field["_register_id"]], field)
+	}
+
+	registerStyle, err := ew.NewHighlightStyle(config.LayoutRegisterBg)
+	if err != nil {
+		return fmt.Errorf("failed to create style: %w", err)
+	}
+
+	namer := newSheetNamer(reserved)
+	for _, peripheral := range peripherals {
+		sheetName := namer.name(peripheral["name"])
+		peripheralRegisters := registersByPeripheral[peripheral["_id"]]
+
+		if err := ew.CreateSheet(sheetName, peripheralSheetHeaders); err != nil {
+			return err
+		}
+		for _, register := range peripheralRegisters {
+			values := []string{
+				clusterQualifiedName(register), "", register["_address"], register["_peripheral_offset"], "",
+				register["_effective_size"], register["_effective_access"], register["_effective_reset_value"],
+				register["_behavior"], register["description"],
+			}
+			cells := make([]interface{}, len(values))
+			for i, value := range values {
+				cells[i] = excelize.Cell{Value: value, StyleID: registerStyle}
+			}
+			if err := ew.WriteCells(sheetName, cells, nil); err != nil {
+				return err
+			}
+
+			for _, field := range fieldsByRegister[register["_id"]] {
+				row := map[string]string{
+					"field":       field["name"],
+					"bits":        field["_bit_range"],
+					"size":        field["bitWidth"],
+					"access":      field["_effective_access"],
+					"resetValue":  field["_reset_value"],
+					"behavior":    field["_behavior"],
+					"description": field["description"],
+				}
+				if err := ew.WriteRow(sheetName, row); err != nil {
+					return err
+				}
+			}
+		}
+
+		link, err := ew.SheetLinkCell(sheetName, sheetName)
+		if err != nil {
+			return err
+		}
+		index := []interface{}{
+			peripheral["_id"], link, peripheral["name"], peripheral["groupName"], peripheral["baseAddress"],
+			peripheral["_end_address"], peripheral["derivedFrom"], strconv.Itoa(len(peripheralRegisters)),
+			peripheral["description"],
+		}
+		if err := ew.WriteCells(indexSheetName, index, nil); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✓ %s: %d peripheral sheets\n", indexSheetName, len(peripherals))
+	return nil
+}
+
+// clusterQualifiedName is the register name prefixed with its cluster path, e.g. CH0.CFG.
+func clusterQualifiedName(register map[string]string) string {
+	if path := register["_cluster_path"]; path != "" {
+		return path + "." + register["name"]
+	}
+	return register["name"]
+}
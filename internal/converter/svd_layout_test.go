@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSheetNamerName(t *testing.T) {
+	long := strings.Repeat("A", 40)
+	tests := []struct {
+		name     string
+		reserved []string
+		in       []string
+		want     []string
+	}{
+		{
+			name: "plain names are kept",
+			in:   []string{"GPIOA", "USART1"},
+			want: []string{"GPIOA", "USART1"},
+		},
+		{
+			name: "invalid characters are replaced",
+			in:   []string{`A:B/C\D?E*F[G]`, "  SPACED  "},
+			want: []string{"A_B_C_D_E_F_G_", "SPACED"},
+		},
+		{
+			name: "apostrophes are trimmed at the ends only",
+			in:   []string{"'QUOTED'", "IT'S"},
+			want: []string{"QUOTED", "IT'S"},
+		},
+		{
+			name: "empty names get a placeholder",
+			in:   []string{"", "''", "Peripheral"},
+			want: []string{"Peripheral", "Peripheral~2", "Peripheral~3"},
+		},
+		{
+			name: "long names are truncated to 31 characters",
+			in:   []string{long},
+			want: []string{long[:31]},
+		},
+		{
+			name: "truncated duplicates keep the suffix within 31 characters",
+			in:   []string{long, long + "B", long},
+			want: []string{long[:31], long[:29] + "~2", long[:29] + "~3"},
+		},
+		{
+			name: "duplicates are case-insensitive",
+			in:   []string{"Timer", "TIMER", "timer"},
+			want: []string{"Timer", "TIMER~2", "timer~3"},
+		},
+		{
+			name:     "reserved names and History are avoided",
+			reserved: []string{"Index", "MemoryMap"},
+			in:       []string{"INDEX", "History", "memorymap"},
+			want:     []string{"INDEX~2", "History~2", "memorymap~2"},
+		},
+		{
+			name: "a suffixed name can collide with a real one",
+			in:   []string{"X", "X~2", "X"},
+			want: []string{"X", "X~2", "X~3"},
+		},
+		{
+			name: "multi-byte names are truncated by character",
+			in:   []string{strings.Repeat("é", 35)},
+			want: []string{strings.Repeat("é", 31)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namer := newSheetNamer(tt.reserved)
+			var got []string
+			for _, name := range tt.in {
+				sheet := namer.name(name)
+				if n := utf8.RuneCountInString(sheet); n > maxSheetNameLength {
+					t.Errorf("name(%q) = %q has %d characters", name, sheet, n)
+				}
+				got = append(got, sheet)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("names = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
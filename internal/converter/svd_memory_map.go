@@ -0,0 +1,168 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/config"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/parser"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/writer"
+	"github.com/xuri/excelize/v2"
+)
+
+const memoryMapSheetName = "MemoryMap"
+
+var memoryMapHeaders = []string{"kind", "name", "groupName", "start", "end", "size", "_size_text", "note", "description"}
+
+// memoryRange is a peripheral placed in the address space.
+type memoryRange struct {
+	peripheral map[string]string
+	start      uint64
+	end        uint64
+	sized      bool
+}
+
+// memoryMapEntry is one MemoryMap row: a peripheral, or a gap when peripheral is nil.
+type memoryMapEntry struct {
+	peripheral map[string]string
+	start      uint64
+	end        uint64
+	sized      bool
+	note       string
+}
+
+// writeMemoryMapSheet adds a sheet listing the peripherals by base address with a gap
+// row for every unmapped range between them. A peripheral's end address comes from its
+// addressBlocks; peripherals starting inside an earlier one are noted as overlapping.
+func writeMemoryMapSheet(ew *writer.ExcelWriter, device, peripherals []map[string]string) error {
+	addressBits := uint64(32)
+	for _, row := range device {
+		if row["property"] == "width" {
+			if width, err := parser.ParseSVDInt(row["value"]); err == nil && width > 0 {
+				addressBits = width
+			}
+		}
+	}
+
+	ranges := memoryRanges(peripherals)
+	entries := memoryMapEntries(ranges)
+
+	if err := ew.CreateSheet(memoryMapSheetName, memoryMapHeaders); err != nil {
+		return err
+	}
+	gapStyle, err := ew.NewFillStyle(config.MemoryMapGapBg)
+	if err != nil {
+		return fmt.Errorf("failed to create style: %w", err)
+	}
+
+	gaps := 0
+	for _, entry := range entries {
+		if entry.peripheral == nil {
+			size := entry.end - entry.start + 1
+			values := []string{"gap", "", "", parser.FormatHexWidth(entry.start, addressBits),
+				parser.FormatHexWidth(entry.end, addressBits), fmt.Sprintf("0x%X", size), sizeText(size), "", ""}
+			gap := make([]interface{}, len(values))
+			for column, value := range values {
+				gap[column] = excelize.Cell{Value: value, StyleID: gapStyle}
+			}
+			if err := ew.WriteCells(memoryMapSheetName, gap, nil); err != nil {
+				return err
+			}
+			gaps++
+			continue
+		}
+
+		row := map[string]string{
+			"kind":        "peripheral",
+			"name":        entry.peripheral["name"],
+			"groupName":   entry.peripheral["groupName"],
+			"start":       parser.FormatHexWidth(entry.start, addressBits),
+			"note":        entry.note,
+			"description": entry.peripheral["description"],
+		}
+		if entry.sized {
+			row["end"] = parser.FormatHexWidth(entry.end, addressBits)
+			row["size"] = fmt.Sprintf("0x%X", entry.end-entry.start+1)
+			row["_size_text"] = sizeText(entry.end - entry.start + 1)
+		}
+		if err := ew.WriteRow(memoryMapSheetName, row); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✓ %s: %d peripherals, %d gaps\n", memoryMapSheetName, len(ranges), gaps)
+	return nil
+}
+
+// memoryRanges places the peripherals with a parsable baseAddress in the address
+// space, sorted by start address.
+func memoryRanges(peripherals []map[string]string) []memoryRange {
+	var ranges []memoryRange
+	for _, peripheral := range peripherals {
+		base, err := parser.ParseSVDInt(peripheral["baseAddress"])
+		if err != nil {
+			continue
+		}
+		r := memoryRange{peripheral: peripheral, start: base, end: base}
+		start, startErr := parser.ParseSVDInt(peripheral["_start_address"])
+		end, endErr := parser.ParseSVDInt(peripheral["_end_address"])
+		if startErr == nil && endErr == nil {
+			r.start, r.end, r.sized = start, end, true
+		}
+		ranges = append(ranges, r)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	return ranges
+}
+
+// memoryMapEntries lists the sorted ranges with a gap entry between two sized
+// peripherals that leave unmapped addresses. A peripheral without addressBlocks has
+// no known end, so no gap is reported next to it.
+func memoryMapEntries(ranges []memoryRange) []memoryMapEntry {
+	var entries []memoryMapEntry
+	var previous *memoryRange
+	previousSized := false
+	for i := range ranges {
+		r := &ranges[i]
+		var notes []string
+		if !r.sized {
+			notes = append(notes, "no addressBlock")
+		}
+		if previous != nil {
+			switch {
+			case r.start > previous.end+1:
+				if r.sized && previousSized {
+					entries = append(entries, memoryMapEntry{start: previous.end + 1, end: r.start - 1})
+				}
+			case r.start <= previous.end:
+				notes = append(notes, "overlaps "+previous.peripheral["name"])
+			}
+		}
+
+		entries = append(entries, memoryMapEntry{
+			peripheral: r.peripheral,
+			start:      r.start,
+			end:        r.end,
+			sized:      r.sized,
+			note:       strings.Join(notes, ", "),
+		})
+
+		previousSized = r.sized
+		if r.sized && (previous == nil || r.end > previous.end) {
+			previous = r
+		}
+	}
+	return entries
+}
+
+// sizeText formats a byte count in the largest binary unit dividing it, e.g. "1 KiB".
+func sizeText(size uint64) string {
+	units := []string{"bytes", "KiB", "MiB", "GiB"}
+	unit := 0
+	for unit < len(units)-1 && size >= 1024 && size%1024 == 0 {
+		size /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%d %s", size, units[unit])
+}
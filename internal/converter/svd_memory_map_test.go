@@ -0,0 +1,117 @@
+package converter
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// peripheralRow returns a Peripherals row; an empty end leaves it without addressBlocks.
+func peripheralRow(name, base, end string) map[string]string {
+	row := map[string]string{"name": name, "baseAddress": base}
+	if end != "" {
+		row["_start_address"] = base
+		row["_end_address"] = end
+	}
+	return row
+}
+
+func TestMemoryMapEntries(t *testing.T) {
+	tests := []struct {
+		name        string
+		peripherals []map[string]string
+		want        []string
+	}{
+		{
+			name: "gap between sized peripherals",
+			peripherals: []map[string]string{
+				peripheralRow("B", "0x40001000", "0x400013FF"),
+				peripheralRow("A", "0x40000000", "0x400003FF"),
+			},
+			want: []string{
+				"A 0x40000000-0x400003FF",
+				"gap 0x40000400-0x40000FFF",
+				"B 0x40001000-0x400013FF",
+			},
+		},
+		{
+			name: "adjacent peripherals leave no gap",
+			peripherals: []map[string]string{
+				peripheralRow("A", "0x40000000", "0x400003FF"),
+				peripheralRow("B", "0x40000400", "0x400007FF"),
+			},
+			want: []string{"A 0x40000000-0x400003FF", "B 0x40000400-0x400007FF"},
+		},
+		{
+			name: "no gaps next to a peripheral without addressBlock",
+			peripherals: []map[string]string{
+				peripheralRow("A", "0x40000000", "0x400003FF"),
+				peripheralRow("NOBLK", "0x40002000", ""),
+				peripheralRow("B", "0x40003000", "0x400033FF"),
+			},
+			want: []string{
+				"A 0x40000000-0x400003FF",
+				"NOBLK 0x40002000 (no addressBlock)",
+				"B 0x40003000-0x400033FF",
+			},
+		},
+		{
+			name: "unsized first peripheral",
+			peripherals: []map[string]string{
+				peripheralRow("NOBLK", "0x40000000", ""),
+				peripheralRow("A", "0x40001000", "0x400013FF"),
+				peripheralRow("B", "0x40002000", "0x400023FF"),
+			},
+			want: []string{
+				"NOBLK 0x40000000 (no addressBlock)",
+				"A 0x40001000-0x400013FF",
+				"gap 0x40001400-0x40001FFF",
+				"B 0x40002000-0x400023FF",
+			},
+		},
+		{
+			name: "overlapping peripherals",
+			peripherals: []map[string]string{
+				peripheralRow("A", "0x40000000", "0x40000FFF"),
+				peripheralRow("B", "0x40000800", "0x400008FF"),
+				peripheralRow("C", "0x40000900", "0x400009FF"),
+				peripheralRow("NOBLK", "0x40000A00", ""),
+			},
+			want: []string{
+				"A 0x40000000-0x40000FFF",
+				"B 0x40000800-0x400008FF (overlaps A)",
+				"C 0x40000900-0x400009FF (overlaps A)",
+				"NOBLK 0x40000A00 (no addressBlock, overlaps A)",
+			},
+		},
+		{
+			name: "peripherals without baseAddress are left out",
+			peripherals: []map[string]string{
+				peripheralRow("A", "0x40000000", "0x400003FF"),
+				peripheralRow("NOBASE", "", ""),
+			},
+			want: []string{"A 0x40000000-0x400003FF"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, entry := range memoryMapEntries(memoryRanges(tt.peripherals)) {
+				text := fmt.Sprintf("gap 0x%X-0x%X", entry.start, entry.end)
+				if entry.peripheral != nil {
+					text = fmt.Sprintf("%s 0x%X", entry.peripheral["name"], entry.start)
+					if entry.sized {
+						text += fmt.Sprintf("-0x%X", entry.end)
+					}
+				}
+				if entry.note != "" {
+					text += " (" + entry.note + ")"
+				}
+				got = append(got, text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/parser"
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/reader"
+)
+
+// svdElementOrder lists the simple child elements of each SVD element in the order
+// the CMSIS-SVD schema requires. Nested elements (address blocks, interrupts,
+// registers, fields, enumerated values) are appended after them.
+var svdElementOrder = map[string][]string{
+	"device": {
+		"vendor", "vendorID", "name", "series", "version", "description", "licenseText", "cpu",
+		"headerSystemFilename", "headerDefinitionsPrefix", "addressUnitBits", "width",
+		"size", "access", "protection", "resetValue", "resetMask", "peripherals",
+	},
+	"cpu": {
+		"name", "revision", "endian", "mpuPresent", "fpuPresent", "fpuDP", "dspPresent",
+		"icachePresent", "dcachePresent", "itcmPresent", "dtcmPresent", "vtorPresent",
+		"nvicPrioBits", "vendorSystickConfig", "deviceNumInterrupts", "sauNumRegions", "sauRegionsConfig",
+	},
+	"peripheral": {
+		"dim", "dimIncrement", "dimIndex", "dimName", "name", "version", "description",
+		"alternatePeripheral", "groupName", "prependToName", "appendToName", "headerStructName",
+		"disableCondition", "baseAddress", "size", "access", "protection", "resetValue", "resetMask",
+	},
+	"addressBlock": {"offset", "size", "usage", "protection"},
+	"interrupt":    {"name", "description", "value"},
+	"cluster": {
+		"dim", "dimIncrement", "dimIndex", "dimName", "name", "description", "alternateCluster",
+		"headerStructName", "addressOffset", "size", "access", "protection", "resetValue", "resetMask",
+	},
+	"register": {
+		"dim", "dimIncrement", "dimIndex", "dimName", "name", "displayName", "description",
+		"alternateGroup", "alternateRegister", "addressOffset", "size", "access", "protection",
+		"resetValue", "resetMask", "dataType", "modifiedWriteValues", "writeConstraint", "readAction",
+	},
+	"field": {
+		"dim", "dimIncrement", "dimIndex", "dimName", "name", "description",
+		"bitOffset", "bitWidth", "lsb", "msb", "access", "modifiedWriteValues", "writeConstraint", "readAction",
+	},
+	"enumeratedValue": {"name", "description", "value", "isDefault"},
+}
+
+// SVDReverseConverter rebuilds an SVD file from a workbook written by SVDConverter.
+type SVDReverseConverter struct {
+	reader   *reader.ExcelReader
+	warnings []string
+	skipped  int
+}
+
+func NewSVDReverseConverter() *SVDReverseConverter {
+	return &SVDReverseConverter{}
+}
+
+// ConvertWorkbook reads the Device, Peripherals, Clusters, Registers, Fields, Interrupts,
+// EnumeratedValues and AddressBlocks sheets, links the rows through their _id and
+// _*_id columns and writes the resulting SVD file. Rows whose parent cannot be found
+// are reported and left out.
+func (c *SVDReverseConverter) ConvertWorkbook(inputFile, outputFile string) error {
+	excelReader, err := reader.NewExcelReader(inputFile)
+	if err != nil {
+		return err
+	}
+	defer excelReader.Close()
+	c.reader = excelReader
+
+	for _, sheet := range []string{"Peripherals", "Registers", "Fields"} {
+		if !excelReader.HasSheet(sheet) {
+			return fmt.Errorf("workbook has no %s sheet", sheet)
+		}
+	}
+
+	device, err := c.buildDevice()
+	if err != nil {
+		return err
+	}
+	counts, err := c.buildPeripherals(device)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range c.warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	if err := parser.WriteSVDTree(outputFile, device); err != nil {
+		return err
+	}
+
+	fmt.Printf("SVD generation completed: %d peripherals, %d clusters, %d registers, %d fields, %d rows skipped\n",
+		counts["peripheral"], counts["cluster"], counts["register"], counts["field"], c.skipped)
+	return nil
+}
+
+// readSheet returns the rows of a sheet, or none when the workbook lacks it, noting
+// which hex element columns hold numeric cells.
+func (c *SVDReverseConverter) readSheet(sheetName string) ([]reader.Row, error) {
+	if !c.reader.HasSheet(sheetName) {
+		return nil, nil
+	}
+	return c.reader.ReadSheet(sheetName, hexElements)
+}
+
+// missingParent records a row skipped because the row it references does not exist.
+func (c *SVDReverseConverter) missingParent(sheetName string, row reader.Row, column string) {
+	c.skipped++
+	c.warnings = append(c.warnings, fmt.Sprintf("%s row %d (%s): %s %q not found",
+		sheetName, row.Number, row.Values["name"], column, row.Values[column]))
+}
+
+// buildDevice rebuilds the device element from the Device sheet's property/value rows,
+// where dotted property names such as cpu.name address nested elements.
+func (c *SVDReverseConverter) buildDevice() (*parser.Element, error) {
+	device := &parser.Element{Name: "device"}
+	rows, err := c.readSheet("Device")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		property, value := row.Values["property"], row.Values["value"]
+		if property == "" {
+			continue
+		}
+		if property == "schemaVersion" {
+			device.SetAttr("schemaVersion", value)
+			continue
+		}
+
+		parts := strings.Split(property, ".")
+		elem := device
+		for _, part := range parts[:len(parts)-1] {
+			child := elem.Child(part)
+			if child == nil {
+				child = &parser.Element{Name: part}
+				elem.Children = append(elem.Children, child)
+			}
+			elem = child
+		}
+		elem.SetChildText(parts[len(parts)-1], value)
+	}
+
+	if device.Attr("schemaVersion") == "" {
+		device.SetAttr("schemaVersion", "1.3")
+	}
+	sortChildren(device)
+	if cpu := device.Child("cpu"); cpu != nil {
+		sortChildren(cpu)
+	}
+	return device, nil
+}
+
+// buildPeripherals attaches the peripherals with their address blocks, interrupts,
+// clusters, registers, fields and enumerated values to the device.
+func (c *SVDReverseConverter) buildPeripherals(device *parser.Element) (map[string]int, error) {
+	counts := make(map[string]int)
+	peripherals := &parser.Element{Name: "peripherals"}
+
+	rows, err := c.readSheet("Peripherals")
+	if err != nil {
+		return nil, err
+	}
+	peripheralByID := make(map[string]*parser.Element)
+	var order []*parser.Element
+	for _, row := range rows {
+		peripheral := rowElement("peripheral", row)
+		peripheralByID[row.Values["_id"]] = peripheral
+		order = append(order, peripheral)
+		counts["peripheral"]++
+	}
+
+	for _, sheet := range []struct{ name, element string }{
+		{"AddressBlocks", "addressBlock"},
+		{"Interrupts", "interrupt"},
+	} {
+		rows, err := c.readSheet(sheet.name)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			peripheral, ok := peripheralByID[row.Values["_peripheral_id"]]
+			if !ok {
+				c.missingParent(sheet.name, row, "_peripheral_id")
+				continue
+			}
+			peripheral.Children = append(peripheral.Children, rowElement(sheet.element, row))
+		}
+	}
+
+	containers, err := c.buildRegisters(peripheralByID, counts)
+	if err != nil {
+		return nil, err
+	}
+	for _, peripheral := range order {
+		if registers := containers[peripheral]; registers != nil {
+			sortRegisterItems(registers)
+			peripheral.Children = append(peripheral.Children, registers)
+		}
+		peripherals.Children = append(peripherals.Children, peripheral)
+	}
+
+	device.RemoveChildren("peripherals")
+	device.Children = append(device.Children, peripherals)
+	sortChildren(device)
+	return counts, nil
+}
+
+// buildRegisters builds the clusters, registers and fields and returns the registers
+// element of each peripheral that has any.
+func (c *SVDReverseConverter) buildRegisters(peripheralByID map[string]*parser.Element, counts map[string]int) (map[*parser.Element]*parser.Element, error) {
+	containers := make(map[*parser.Element]*parser.Element)
+	registersOf := func(peripheral *parser.Element) *parser.Element {
+		if containers[peripheral] == nil {
+			containers[peripheral] = &parser.Element{Name: "registers"}
+		}
+		return containers[peripheral]
+	}
+
+	// Clusters are created before they are nested, so parents may appear after children
+	clusterRows, err := c.readSheet("Clusters")
+	if err != nil {
+		return nil, err
+	}
+	clusterByID := make(map[string]*parser.Element)
+	for _, row := range clusterRows {
+		clusterByID[row.Values["_id"]] = rowElement("cluster", row)
+	}
+	for _, row := range clusterRows {
+		cluster := clusterByID[row.Values["_id"]]
+		if parentID := row.Values["_parent_cluster_id"]; parentID != "" {
+			parent, ok := clusterByID[parentID]
+			if !ok {
+				c.missingParent("Clusters", row, "_parent_cluster_id")
+				continue
+			}
+			parent.Children = append(parent.Children, cluster)
+		} else {
+			peripheral, ok := peripheralByID[row.Values["_peripheral_id"]]
+			if !ok {
+				c.missingParent("Clusters", row, "_peripheral_id")
+				continue
+			}
+			registersOf(peripheral).Children = append(registersOf(peripheral).Children, cluster)
+		}
+		counts["cluster"]++
+	}
+
+	registerRows, err := c.readSheet("Registers")
+	if err != nil {
+		return nil, err
+	}
+	registerByID := make(map[string]*parser.Element)
+	for _, row := range registerRows {
+		register := rowElement("register", row)
+		if clusterID := row.Values["_cluster_id"]; clusterID != "" {
+			cluster, ok := clusterByID[clusterID]
+			if !ok {
+				c.missingParent("Registers", row, "_cluster_id")
+				continue
+			}
+			cluster.Children = append(cluster.Children, register)
+		} else {
+			peripheral, ok := peripheralByID[row.Values["_peripheral_id"]]
+			if !ok {
+				c.missingParent("Registers", row, "_peripheral_id")
+				continue
+			}
+			registersOf(peripheral).Children = append(registersOf(peripheral).Children, register)
+		}
+		registerByID[row.Values["_id"]] = register
+		counts["register"]++
+	}
+	for _, cluster := range clusterByID {
+		sortRegisterItems(cluster)
+	}
+
+	fieldByID, err := c.buildFields(registerByID, counts)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.buildEnumeratedValues(fieldByID); err != nil {
+		return nil, err
+	}
+
+	return containers, nil
+}
+
+// buildFields attaches the Fields rows to their registers.
+func (c *SVDReverseConverter) buildFields(registerByID map[string]*parser.Element, counts map[string]int) (map[string]*parser.Element, error) {
+	rows, err := c.readSheet("Fields")
+	if err != nil {
+		return nil, err
+	}
+
+	fieldByID := make(map[string]*parser.Element)
+	for _, row := range rows {
+		register, ok := registerByID[row.Values["_register_id"]]
+		if !ok {
+			c.missingParent("Fields", row, "_register_id")
+			continue
+		}
+
+		field := rowElement("field", row)
+		// Every bit notation is filled on the Fields sheet; bitOffset/bitWidth is kept
+		if field.Child("bitOffset") != nil {
+			field.RemoveChildren("lsb")
+			field.RemoveChildren("msb")
+		}
+
+		fields := register.Child("fields")
+		if fields == nil {
+			fields = &parser.Element{Name: "fields"}
+			register.Children = append(register.Children, fields)
+		}
+		fields.Children = append(fields.Children, field)
+		fieldByID[row.Values["_id"]] = field
+		counts["field"]++
+	}
+
+	return fieldByID, nil
+}
+
+// buildEnumeratedValues groups the EnumeratedValues rows of each field into sets by
+// enumeratedValuesName, usage and derivedFrom.
+func (c *SVDReverseConverter) buildEnumeratedValues(fieldByID map[string]*parser.Element) error {
+	rows, err := c.readSheet("EnumeratedValues")
+	if err != nil {
+		return err
+	}
+
+	sets := make(map[string]*parser.Element)
+	for _, row := range rows {
+		field, ok := fieldByID[row.Values["_field_id"]]
+		if !ok {
+			c.missingParent("EnumeratedValues", row, "_field_id")
+			continue
+		}
+
+		key := strings.Join([]string{row.Values["_field_id"], row.Values["enumeratedValuesName"],
+			row.Values["usage"], row.Values["derivedFrom"]}, "\x00")
+		set, ok := sets[key]
+		if !ok {
+			set = &parser.Element{Name: "enumeratedValues"}
+			set.SetAttr("derivedFrom", row.Values["derivedFrom"])
+			if name := row.Values["enumeratedValuesName"]; name != "" {
+				set.SetChildText("name", name)
+			}
+			if usage := row.Values["usage"]; usage != "" {
+				set.SetChildText("usage", usage)
+			}
+			sets[key] = set
+			field.Children = append(field.Children, set)
+		}
+
+		value := rowElement("enumeratedValue", row)
+		value.SetAttr("derivedFrom", "")
+		set.Children = append(set.Children, value)
+	}
+
+	return nil
+}
+
+// rowElement builds an element from the SVD columns of a row in schema order. Computed
+// columns (prefixed with _) are ignored.
+func rowElement(name string, row reader.Row) *parser.Element {
+	elem := &parser.Element{Name: name}
+	elem.SetAttr("derivedFrom", row.Values["derivedFrom"])
+
+	for _, child := range svdElementOrder[name] {
+		value := row.Values[child]
+		if value == "" {
+			continue
+		}
+		if child == "writeConstraint" {
+			if constraint := parser.WriteConstraintElement(value); constraint != nil {
+				elem.Children = append(elem.Children, constraint)
+			}
+			continue
+		}
+		if hexElements[child] && row.Numeric[child] {
+			value = hexAddress(value)
+		}
+		elem.Children = append(elem.Children, &parser.Element{Name: child, Text: value})
+	}
+
+	return elem
+}
+
+// hexElements are the address and register value elements written in hex.
+var hexElements = map[string]bool{
+	"baseAddress":   true,
+	"addressOffset": true,
+	"offset":        true,
+	"dimIncrement":  true,
+	"resetValue":    true,
+	"resetMask":     true,
+}
+
+// hexAddress rewrites a plain decimal number read back from a numeric cell in hex.
+// Text cells are not passed here, so values the user typed keep their notation.
+func hexAddress(value string) string {
+	if strings.Trim(value, "0123456789") != "" {
+		return value
+	}
+	number, err := parser.ParseSVDInt(value)
+	if err != nil {
+		return value
+	}
+	return fmt.Sprintf("0x%X", number)
+}
+
+// sortChildren orders the children of an element by schema order; unknown elements keep
+// their relative order at the end.
+func sortChildren(elem *parser.Element) {
+	rank := make(map[string]int)
+	for i, name := range svdElementOrder[elem.Name] {
+		rank[name] = i + 1
+	}
+	position := func(c *parser.Element) int {
+		if r, ok := rank[c.Name]; ok {
+			return r
+		}
+		return len(rank) + 1
+	}
+	sort.SliceStable(elem.Children, func(i, j int) bool {
+		return position(elem.Children[i]) < position(elem.Children[j])
+	})
+}
+
+// sortRegisterItems orders the registers and clusters of a registers or cluster element
+// by address offset, after the element's own properties.
+func sortRegisterItems(elem *parser.Element) {
+	offset := func(c *parser.Element) uint64 {
+		value, err := parser.ParseSVDInt(c.ChildText("addressOffset"))
+		if err != nil {
+			return ^uint64(0)
+		}
+		return value
+	}
+	isItem := func(c *parser.Element) bool {
+		return c.Name == "register" || c.Name == "cluster"
+	}
+	sort.SliceStable(elem.Children, func(i, j int) bool {
+		a, b := elem.Children[i], elem.Children[j]
+		if isItem(a) != isItem(b) {
+			return !isItem(a)
+		}
+		return isItem(a) && offset(a) < offset(b)
+	})
+}
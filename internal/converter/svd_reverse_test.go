@@ -0,0 +1,47 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/TomyTang331/Xml2ExcelByGo/internal/reader"
+)
+
+func TestRowElementHexOnlyForNumericCells(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]string
+		numeric map[string]bool
+		want    []string
+	}{
+		{
+			name:    "numeric cells are written in hex",
+			values:  map[string]string{"name": "CR", "addressOffset": "16", "resetValue": "255"},
+			numeric: map[string]bool{"addressOffset": true, "resetValue": true},
+			want:    []string{"name=CR", "addressOffset=0x10", "resetValue=0xFF"},
+		},
+		{
+			name:   "text cells keep their notation",
+			values: map[string]string{"name": "CR", "addressOffset": "16", "resetValue": "00000010"},
+			want:   []string{"name=CR", "addressOffset=16", "resetValue=00000010"},
+		},
+		{
+			name:    "hex text in a numeric column is kept",
+			values:  map[string]string{"name": "CR", "addressOffset": "0x10", "size": "32"},
+			numeric: map[string]bool{"size": true},
+			want:    []string{"name=CR", "addressOffset=0x10", "size=32"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elem := rowElement("register", reader.Row{Values: tt.values, Numeric: tt.numeric})
+			var got []string
+			for _, child := range elem.Children {
+				got = append(got, child.Name+"="+child.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("children = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,178 +1,652 @@
 package parser
 
 import (
-	"bufio"
-	"encoding/xml"
 	"fmt"
-	"io"
-	"os"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 const (
 	peripheralIDPrefix = "P"
+	clusterIDPrefix    = "C"
 	registerIDPrefix   = "R"
 	fieldIDPrefix      = "F"
+	interruptIDPrefix  = "I"
+	enumIDPrefix       = "E"
+	blockIDPrefix      = "A"
 	idFormatWidth      = 4
 )
 
+// IDMode selects how row IDs are formed.
+type IDMode string
+
+const (
+	// IDModeNumeric numbers rows per sheet in document order (P0000, R0000, ...).
+	IDModeNumeric IDMode = "numeric"
+	// IDModePath uses the element's dotted name path (GPIOA.MODER.MODER5) as its ID.
+	IDModePath IDMode = "path"
+	// IDModeBoth keeps numeric IDs and adds the name path in a _path column.
+	IDModeBoth IDMode = "both"
+)
+
+// SVDOptions controls how the SVD tree is flattened into rows.
+type SVDOptions struct {
+	// KeepDimArrays writes dim arrays as a single row instead of one row per instance.
+	KeepDimArrays bool
+
+	// IDMode selects numeric, path or both kinds of row IDs; empty means numeric.
+	IDMode IDMode
+
+	// PatchFile is an svdtools-style YAML patch applied to the device before
+	// derivedFrom resolution.
+	PatchFile string
+
+	// Include and Exclude are glob patterns selecting peripherals by name or groupName,
+	// or registers by path such as USART*.CR*. An empty Include keeps every peripheral.
+	Include []string
+	Exclude []string
+}
+
 // SVDParser parses CMSIS-SVD format XML files
 type SVDParser struct {
 	bufferSize int
+	options    SVDOptions
+}
+
+func NewSVDParser(bufferSize int, options SVDOptions) *SVDParser {
+	return &SVDParser{bufferSize: bufferSize, options: options}
 }
 
-func NewSVDParser(bufferSize int) *SVDParser {
-	return &SVDParser{bufferSize: bufferSize}
+// SVDStreams holds one row channel per output sheet plus the error channel.
+type SVDStreams struct {
+	Device      <-chan map[string]string
+	Peripherals <-chan map[string]string
+	Clusters    <-chan map[string]string
+	Registers   <-chan map[string]string
+	Fields      <-chan map[string]string
+	Interrupts  <-chan map[string]string
+	Enums       <-chan map[string]string
+	Blocks      <-chan map[string]string
+	Issues      <-chan map[string]string
+	Errors      <-chan error
 }
 
-// ParseSVD parses SVD file and returns 3 channels for peripherals, registers, and fields
-func (p *SVDParser) ParseSVD(filename string) (
-	<-chan map[string]string,
-	<-chan map[string]string,
-	<-chan map[string]string,
-	<-chan error,
-) {
+// ParseSVD parses SVD file and returns row channels for device properties, peripherals,
+// clusters, registers, fields, interrupts, enumerated values, address blocks and
+// register map layout issues.
+// derivedFrom references are resolved before rows are emitted, so derived elements
+// carry the registers and fields inherited from their base element, and dim arrays
+// are expanded into instances unless KeepDimArrays is set. The Include and Exclude
+// filters apply after both, so filtered-out bases still resolve.
+func (p *SVDParser) ParseSVD(filename string) SVDStreams {
+	deviceChan := make(chan map[string]string, channelBufferSize)
 	peripheralChan := make(chan map[string]string, channelBufferSize)
+	clusterChan := make(chan map[string]string, channelBufferSize)
 	registerChan := make(chan map[string]string, channelBufferSize)
 	fieldChan := make(chan map[string]string, channelBufferSize)
+	interruptChan := make(chan map[string]string, channelBufferSize)
+	enumChan := make(chan map[string]string, channelBufferSize)
+	blockChan := make(chan map[string]string, channelBufferSize)
+	issueChan := make(chan map[string]string, channelBufferSize)
 	errChan := make(chan error, 1)
 
 	go func() {
+		defer close(deviceChan)
 		defer close(peripheralChan)
+		defer close(clusterChan)
 		defer close(registerChan)
 		defer close(fieldChan)
+		defer close(interruptChan)
+		defer close(enumChan)
+		defer close(blockChan)
+		defer close(issueChan)
 		defer close(errChan)
 
-		file, err := os.Open(filename)
+		device, err := ReadSVDTree(filename, p.bufferSize)
 		if err != nil {
 			errChan <- err
 			return
 		}
-		defer file.Close()
+		if device.Name != "device" {
+			errChan <- fmt.Errorf("not a CMSIS-SVD file: root element is <%s>", device.Name)
+			return
+		}
+
+		if p.options.PatchFile != "" {
+			warnings, err := applyPatchFile(device, p.options.PatchFile)
+			printWarnings(warnings)
+			if err != nil {
+				errChan <- fmt.Errorf("failed to apply patch: %w", err)
+				return
+			}
+			fmt.Printf("Applied patch %s\n", p.options.PatchFile)
+		}
 
-		reader := bufio.NewReaderSize(file, p.bufferSize)
-		decoder := xml.NewDecoder(reader)
+		printWarnings(resolveDerivedFrom(device))
+
+		if !p.options.KeepDimArrays {
+			printWarnings(expandDimArrays(device))
+		}
 
-		var currentPeripheral map[string]string
-		var currentRegister map[string]string
-		var currentField map[string]string
+		if len(p.options.Include) > 0 || len(p.options.Exclude) > 0 {
+			fmt.Println(filterPeripherals(device, p.options.Include, p.options.Exclude))
+		}
 
-		var pathStack []string
-		var textBuilder string
+		f := &svdFlattener{
+			idMode:         p.options.IDMode,
+			paths:          make(map[string]bool),
+			deviceChan:     deviceChan,
+			peripheralChan: peripheralChan,
+			clusterChan:    clusterChan,
+			registerChan:   registerChan,
+			fieldChan:      fieldChan,
+			interruptChan:  interruptChan,
+			enumChan:       enumChan,
+			blockChan:      blockChan,
+		}
+		f.flattenDevice(device)
+		printWarnings(f.flushInterrupts())
 
-		peripheralCount := 0
-		registerCount := 0
-		fieldCount := 0
+		fmt.Printf("SVD parsing completed: %d peripherals, %d clusters, %d registers, %d fields, %d interrupts, %d enumerated values\n",
+			f.peripheralCount, f.clusterCount, f.registerCount, f.fieldCount, len(f.interrupts), f.enumCount)
 
-		for {
-			token, err := decoder.Token()
-			if err == io.EOF {
-				break
+		errorCount := 0
+		issues := validateLayout(device)
+		for _, issue := range issues {
+			if issue.Severity == SeverityError {
+				errorCount++
 			}
-			if err != nil {
-				errChan <- fmt.Errorf("XML parsing error: %w", err)
-				return
+			issueChan <- issue.Row()
+		}
+		fmt.Printf("Layout validation: %d errors, %d warnings\n", errorCount, len(issues)-errorCount)
+	}()
+
+	return SVDStreams{
+		Device:      deviceChan,
+		Peripherals: peripheralChan,
+		Clusters:    clusterChan,
+		Registers:   registerChan,
+		Fields:      fieldChan,
+		Interrupts:  interruptChan,
+		Enums:       enumChan,
+		Blocks:      blockChan,
+		Issues:      issueChan,
+		Errors:      errChan,
+	}
+}
+
+// svdFlattener turns a resolved SVD tree into rows for each sheet.
+type svdFlattener struct {
+	idMode IDMode
+	// paths holds the path IDs handed out so far, keyed by ID prefix and path.
+	paths map[string]bool
+
+	deviceChan     chan<- map[string]string
+	peripheralChan chan<- map[string]string
+	clusterChan    chan<- map[string]string
+	registerChan   chan<- map[string]string
+	fieldChan      chan<- map[string]string
+	interruptChan  chan<- map[string]string
+	enumChan       chan<- map[string]string
+	blockChan      chan<- map[string]string
+
+	peripheralCount int
+	clusterCount    int
+	registerCount   int
+	fieldCount      int
+	enumCount       int
+	blockCount      int
+
+	// interrupts are held back until all peripherals are read so they can be sorted by IRQ number.
+	interrupts []map[string]string
+
+	deviceProperties propertySet
+	addressBits      uint64
+}
+
+// registerScope describes where a register sits: its peripheral, the enclosing
+// cluster (nil at peripheral level) with its dotted name path, the offset from the
+// peripheral base address and the register properties inherited so far.
+type registerScope struct {
+	peripheralRow map[string]string
+	clusterRow    map[string]string
+	clusterPath   string
+	offset        uint64
+	properties    propertySet
+}
+
+func (f *svdFlattener) flattenDevice(device *Element) {
+	if version := device.Attr("schemaVersion"); version != "" {
+		f.deviceChan <- map[string]string{"property": "schemaVersion", "value": version}
+	}
+	f.flattenDeviceProperties(device, "")
+	f.deviceProperties = defaultProperties().inherit(device, "device")
+	f.addressBits = defaultRegisterSize
+	if width, err := ParseSVDInt(device.ChildText("width")); err == nil && width > 0 {
+		f.addressBits = width
+	}
+
+	peripherals := device.Child("peripherals")
+	if peripherals == nil {
+		return
+	}
+
+	for _, peripheral := range peripherals.ChildrenNamed("peripheral") {
+		f.flattenPeripheral(peripheral)
+	}
+}
+
+// flattenDeviceProperties emits device-level elements as property/value rows in
+// document order, naming nested elements such as the cpu block with dotted paths.
+func (f *svdFlattener) flattenDeviceProperties(elem *Element, prefix string) {
+	for _, c := range elem.Children {
+		if c.Name == "peripherals" || c.Name == "vendorExtensions" {
+			continue
+		}
+		if !c.IsLeaf() {
+			f.flattenDeviceProperties(c, prefix+c.Name+".")
+			continue
+		}
+		if c.Text != "" {
+			f.deviceChan <- map[string]string{"property": prefix + c.Name, "value": c.Text}
+		}
+	}
+}
+
+func (f *svdFlattener) flattenPeripheral(peripheral *Element) {
+	row := leafValues(peripheral)
+	f.assignID(row, peripheralIDPrefix, f.peripheralCount, row["name"])
+	f.peripheralCount++
+
+	blockRows := f.addressBlockRows(peripheral, row)
+	f.peripheralChan <- row
+
+	for i, blockRow := range blockRows {
+		f.assignID(blockRow, blockIDPrefix, f.blockCount, fmt.Sprintf("%s.addressBlock[%d]", row["_path"], i))
+		f.blockCount++
+		f.blockChan <- blockRow
+	}
+
+	for _, interrupt := range peripheral.ChildrenNamed("interrupt") {
+		interruptRow := leafValues(interrupt)
+		interruptRow["_peripheral_id"] = row["_id"]
+		interruptRow["_peripheral_index"] = row["_index"]
+		interruptRow["_peripheral_name"] = row["name"]
+		f.interrupts = append(f.interrupts, interruptRow)
+	}
+
+	if registers := peripheral.Child("registers"); registers != nil {
+		f.flattenRegisters(registers, &registerScope{
+			peripheralRow: row,
+			properties:    f.deviceProperties.inherit(peripheral, "peripheral"),
+		})
+	}
+}
+
+// addressBlockRows builds the address block rows of a peripheral and sets the
+// peripheral's overall start and end address from the blocks it declares.
+func (f *svdFlattener) addressBlockRows(peripheral *Element, peripheralRow map[string]string) []map[string]string {
+	baseAddress, baseErr := ParseSVDInt(peripheralRow["baseAddress"])
+
+	var rows []map[string]string
+	var start, end uint64
+	for _, block := range peripheral.ChildrenNamed("addressBlock") {
+		row := leafValues(block)
+		row["_peripheral_id"] = peripheralRow["_id"]
+		row["_peripheral_index"] = peripheralRow["_index"]
+		row["_peripheral_name"] = peripheralRow["name"]
+
+		offset, offsetErr := ParseSVDInt(row["offset"])
+		size, sizeErr := ParseSVDInt(row["size"])
+		if baseErr == nil && offsetErr == nil && sizeErr == nil && size > 0 {
+			blockStart := baseAddress + offset
+			blockEnd := blockStart + size - 1
+			row["_start_address"] = f.formatAddress(blockStart)
+			row["_end_address"] = f.formatAddress(blockEnd)
+
+			if peripheralRow["_start_address"] == "" || blockStart < start {
+				start = blockStart
+				peripheralRow["_start_address"] = f.formatAddress(start)
+			}
+			if peripheralRow["_end_address"] == "" || blockEnd > end {
+				end = blockEnd
+				peripheralRow["_end_address"] = f.formatAddress(end)
 			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
 
-			switch elem := token.(type) {
-			case xml.StartElement:
-				pathStack = append(pathStack, elem.Name.Local)
-				textBuilder = ""
-
-				// Detect peripheral start
-				if elem.Name.Local == "peripheral" && isInPath(pathStack, "peripherals") {
-					currentPeripheral = make(map[string]string)
-					currentPeripheral["_id"] = fmt.Sprintf("%s%0*d", peripheralIDPrefix, idFormatWidth, peripheralCount)
-					peripheralCount++
-				}
-
-				// Detect register start
-				if elem.Name.Local == "register" && isInPath(pathStack, "registers") && currentPeripheral != nil {
-					currentRegister = make(map[string]string)
-					currentRegister["_id"] = fmt.Sprintf("%s%0*d", registerIDPrefix, idFormatWidth, registerCount)
-					currentRegister["_peripheral_id"] = currentPeripheral["_id"]
-					currentRegister["_peripheral_name"] = currentPeripheral["name"]
-					registerCount++
-				}
-
-				// Detect field start
-				if elem.Name.Local == "field" && isInPath(pathStack, "fields") && currentRegister != nil {
-					currentField = make(map[string]string)
-					currentField["_id"] = fmt.Sprintf("%s%0*d", fieldIDPrefix, idFormatWidth, fieldCount)
-					currentField["_register_id"] = currentRegister["_id"]
-					currentField["_register_name"] = currentRegister["name"]
-					currentField["_peripheral_id"] = currentRegister["_peripheral_id"]
-					currentField["_peripheral_name"] = currentRegister["_peripheral_name"]
-					fieldCount++
-				}
-
-			case xml.CharData:
-				textBuilder += string(elem)
-
-			case xml.EndElement:
-				text := strings.TrimSpace(textBuilder)
-
-				// Save peripheral child elements
-				if currentPeripheral != nil && len(pathStack) >= 2 {
-					parentElement := pathStack[len(pathStack)-2]
-					if parentElement == "peripheral" && elem.Name.Local != "peripheral" && elem.Name.Local != "registers" && text != "" {
-						currentPeripheral[elem.Name.Local] = text
-					}
-				}
-
-				// Save register child elements
-				if currentRegister != nil && len(pathStack) >= 2 {
-					parentElement := pathStack[len(pathStack)-2]
-					if parentElement == "register" && elem.Name.Local != "register" && elem.Name.Local != "fields" && text != "" {
-						currentRegister[elem.Name.Local] = text
-					}
-				}
-
-				// Save field child elements
-				if currentField != nil && len(pathStack) >= 2 {
-					parentElement := pathStack[len(pathStack)-2]
-					if parentElement == "field" && text != "" {
-						currentField[elem.Name.Local] = text
-					}
-				}
-
-				// Send completed peripheral
-				if elem.Name.Local == "peripheral" && currentPeripheral != nil {
-					peripheralChan <- currentPeripheral
-					currentPeripheral = nil
-				}
-
-				// Send completed register
-				if elem.Name.Local == "register" && currentRegister != nil {
-					registerChan <- currentRegister
-					currentRegister = nil
-				}
-
-				// Send completed field
-				if elem.Name.Local == "field" && currentField != nil {
-					fieldChan <- currentField
-					currentField = nil
-				}
-
-				if len(pathStack) > 0 {
-					pathStack = pathStack[:len(pathStack)-1]
-				}
-				textBuilder = ""
+// formatAddress formats an absolute address with the device's address width.
+func (f *svdFlattener) formatAddress(address uint64) string {
+	return FormatHexWidth(address, f.addressBits)
+}
+
+// flattenRegisters emits the clusters and registers of a registers or cluster element.
+func (f *svdFlattener) flattenRegisters(container *Element, scope *registerScope) {
+	for _, item := range registerItems(container) {
+		if item.Name == "cluster" {
+			f.flattenCluster(item, scope)
+			continue
+		}
+		f.flattenRegister(item, scope)
+	}
+}
+
+func (f *svdFlattener) flattenCluster(cluster *Element, parent *registerScope) {
+	row := leafValues(cluster)
+	row["_peripheral_id"] = parent.peripheralRow["_id"]
+	row["_peripheral_index"] = parent.peripheralRow["_index"]
+	row["_peripheral_name"] = parent.peripheralRow["name"]
+
+	scope := &registerScope{
+		peripheralRow: parent.peripheralRow,
+		clusterRow:    row,
+		clusterPath:   row["name"],
+		offset:        parent.offset,
+		properties:    parent.properties.inherit(cluster, "cluster"),
+	}
+	if parent.clusterRow != nil {
+		row["_parent_cluster_id"] = parent.clusterRow["_id"]
+		row["_parent_cluster_name"] = parent.clusterRow["name"]
+		scope.clusterPath = parent.clusterPath + "." + row["name"]
+	}
+	row["_cluster_path"] = scope.clusterPath
+	f.assignID(row, clusterIDPrefix, f.clusterCount, parent.peripheralRow["_path"]+"."+scope.clusterPath)
+	f.clusterCount++
+
+	if offset, err := ParseSVDInt(row["addressOffset"]); err == nil {
+		scope.offset += offset
+		row["_peripheral_offset"] = formatHex(scope.offset)
+		if baseAddress, err := ParseSVDInt(scope.peripheralRow["baseAddress"]); err == nil {
+			row["_address"] = f.formatAddress(baseAddress + scope.offset)
+		}
+	}
+
+	f.clusterChan <- row
+	f.flattenRegisters(cluster, scope)
+}
+
+func (f *svdFlattener) flattenRegister(register *Element, scope *registerScope) {
+	row := leafValues(register)
+	row["_peripheral_id"] = scope.peripheralRow["_id"]
+	row["_peripheral_index"] = scope.peripheralRow["_index"]
+	row["_peripheral_name"] = scope.peripheralRow["name"]
+
+	path := scope.peripheralRow["_path"] + "." + row["name"]
+	if scope.clusterRow != nil {
+		row["_cluster_id"] = scope.clusterRow["_id"]
+		row["_cluster_path"] = scope.clusterPath
+		path = scope.clusterRow["_path"] + "." + row["name"]
+	}
+	f.assignID(row, registerIDPrefix, f.registerCount, path)
+	if offset, err := ParseSVDInt(row["addressOffset"]); err == nil {
+		row["_peripheral_offset"] = formatHex(scope.offset + offset)
+		if baseAddress, err := ParseSVDInt(scope.peripheralRow["baseAddress"]); err == nil {
+			row["_address"] = f.formatAddress(baseAddress + scope.offset + offset)
+		}
+	}
+
+	properties := scope.properties.inherit(register, "register")
+	properties.apply(row, registerPropertyNames)
+	applyBehavior(register, row, nil)
+	registerSize := properties.size()
+
+	var fields []*Element
+	if container := register.Child("fields"); container != nil {
+		fields = container.ChildrenNamed("field")
+	}
+	if len(fields) > 0 {
+		row["_first_field_index"] = strconv.Itoa(f.fieldCount)
+	}
+
+	f.registerCount++
+	f.registerChan <- row
+
+	for _, field := range fields {
+		f.flattenField(field, row, properties, registerSize)
+	}
+}
+
+func (f *svdFlattener) flattenField(field *Element, registerRow map[string]string, registerProperties propertySet, registerSize uint64) {
+	row := leafValues(field)
+	f.assignID(row, fieldIDPrefix, f.fieldCount, registerRow["_path"]+"."+row["name"])
+	row["_register_id"] = registerRow["_id"]
+	row["_register_index"] = registerRow["_index"]
+	row["_register_name"] = registerRow["name"]
+	row["_peripheral_id"] = registerRow["_peripheral_id"]
+	row["_peripheral_index"] = registerRow["_peripheral_index"]
+	row["_peripheral_name"] = registerRow["_peripheral_name"]
+
+	registerProperties.inherit(field, "field").apply(row, fieldPropertyNames)
+	applyBehavior(field, row, registerRow)
+
+	normalizeFieldPosition(field, row, registerSize)
+	fieldResetValue(field, row, registerProperties)
+
+	f.fieldCount++
+	f.fieldChan <- row
+
+	for _, enumSet := range field.ChildrenNamed("enumeratedValues") {
+		f.flattenEnumeratedValues(enumSet, row)
+	}
+}
+
+// normalizeFieldPosition fills the unified bitOffset/bitWidth/lsb/msb columns from
+// whichever notation the field uses, with the computed bit range and mask, and records
+// the source notation and any conflict between notations.
+func normalizeFieldPosition(field *Element, row map[string]string, registerSize uint64) {
+	positions := fieldBitPositions(field)
+	if len(positions) == 0 {
+		return
+	}
+
+	notations := make([]string, len(positions))
+	for i, position := range positions {
+		notations[i] = position.notation
+	}
+	row["_bit_notation"] = strings.Join(notations, ", ")
+	if conflict := bitPositionConflict(positions); conflict != "" {
+		row["_bit_conflict"] = conflict
+	}
+
+	lsb, width, err := fieldBitPosition(field)
+	if err != nil {
+		return
+	}
+	msb := lsb + width - 1
+	row["bitOffset"] = strconv.FormatUint(lsb, 10)
+	row["bitWidth"] = strconv.FormatUint(width, 10)
+	row["lsb"] = strconv.FormatUint(lsb, 10)
+	row["msb"] = strconv.FormatUint(msb, 10)
+	row["_bit_range"] = fmt.Sprintf("[%d:%d]", msb, lsb)
+	row["_mask"] = FormatHexWidth(bitMask(lsb, width), registerSize)
+}
+
+// fieldResetValue extracts the field's bits from the effective register reset value,
+// marks whether resetMask defines them and names the matching enumerated value. A
+// reset value declared nowhere in the hierarchy leaves the field's reset state undefined.
+func fieldResetValue(field *Element, row map[string]string, registerProperties propertySet) {
+	lsb, width, err := fieldBitPosition(field)
+	if err != nil {
+		return
+	}
+	if registerProperties["resetValue"].source == "default" {
+		row["_reset_defined"] = "no"
+		return
+	}
+
+	resetValue, err := ParseSVDInt(registerProperties["resetValue"].value)
+	if err != nil {
+		return
+	}
+	resetMask, err := ParseSVDInt(registerProperties["resetMask"].value)
+	if err != nil {
+		resetMask = ^uint64(0)
+	}
+
+	mask := bitMask(lsb, width)
+	value := (resetValue & mask) >> lsb
+	row["_reset_value"] = FormatHexWidth(value, width)
+	row["_reset_value_dec"] = strconv.FormatUint(value, 10)
+
+	switch resetMask & mask {
+	case mask:
+		row["_reset_defined"] = "yes"
+	case 0:
+		row["_reset_defined"] = "no"
+	default:
+		row["_reset_defined"] = "partial"
+	}
+
+	if name := enumeratedValueName(field, value); name != "" {
+		row["_reset_enum"] = name
+	}
+}
+
+// enumeratedValueName returns the name of the enumerated value matching value,
+// preferring sets readable by software and falling back to an isDefault entry.
+func enumeratedValueName(field *Element, value uint64) string {
+	sets := field.ChildrenNamed("enumeratedValues")
+	sort.SliceStable(sets, func(i, j int) bool {
+		return sets[i].ChildText("usage") != "write" && sets[j].ChildText("usage") == "write"
+	})
+
+	defaultName := ""
+	for _, set := range sets {
+		for _, enumValue := range set.ChildrenNamed("enumeratedValue") {
+			if enumValue.ChildText("isDefault") == "true" && defaultName == "" {
+				defaultName = enumValue.ChildText("name")
+				continue
 			}
+			if matchEnumValue(enumValue.ChildText("value"), value) {
+				return enumValue.ChildText("name")
+			}
+		}
+	}
+	return defaultName
+}
+
+func (f *svdFlattener) flattenEnumeratedValues(enumSet *Element, fieldRow map[string]string) {
+	usage := enumSet.ChildText("usage")
+	if usage == "" {
+		usage = "read-write"
+	}
+
+	for _, value := range enumSet.ChildrenNamed("enumeratedValue") {
+		row := leafValues(value)
+		f.assignID(row, enumIDPrefix, f.enumCount, fieldRow["_path"]+"."+row["name"])
+		row["_field_id"] = fieldRow["_id"]
+		row["_field_name"] = fieldRow["name"]
+		row["_register_id"] = fieldRow["_register_id"]
+		row["_register_index"] = fieldRow["_register_index"]
+		row["_register_name"] = fieldRow["_register_name"]
+		row["_peripheral_id"] = fieldRow["_peripheral_id"]
+		row["_peripheral_index"] = fieldRow["_peripheral_index"]
+		row["_peripheral_name"] = fieldRow["_peripheral_name"]
+		row["enumeratedValuesName"] = enumSet.ChildText("name")
+		row["usage"] = usage
+		if ref := enumSet.Attr("derivedFrom"); ref != "" {
+			row["derivedFrom"] = ref
 		}
+		f.enumCount++
+		f.enumChan <- row
+	}
+}
 
-		fmt.Printf("SVD parsing completed: %d peripherals, %d registers, %d fields\n",
-			peripheralCount, registerCount, fieldCount)
-	}()
+// assignID sets a row's _id according to the ID mode. The name path is always kept
+// in _path so child rows can extend it; paths repeated within one kind of row get a
+// "#2", "#3", ... suffix to stay unique. _index keeps the row's position among the
+// rows of its kind, which child rows copy (_peripheral_index, _register_index) so the
+// writer can link straight to the referenced row.
+func (f *svdFlattener) assignID(row map[string]string, prefix string, number int, path string) {
+	row["_index"] = strconv.Itoa(number)
+	unique := path
+	for n := 2; f.paths[prefix+unique]; n++ {
+		unique = fmt.Sprintf("%s#%d", path, n)
+	}
+	f.paths[prefix+unique] = true
+	row["_path"] = unique
 
-	return peripheralChan, registerChan, fieldChan, errChan
+	if f.idMode == IDModePath {
+		row["_id"] = unique
+		return
+	}
+	row["_id"] = fmt.Sprintf("%s%0*d", prefix, idFormatWidth, number)
 }
 
-func isInPath(stack []string, target string) bool {
-	for _, s := range stack {
-		if s == target {
-			return true
+// flushInterrupts emits the collected interrupts sorted by IRQ number and returns
+// warnings for IRQ numbers that are shared by differently named interrupts.
+func (f *svdFlattener) flushInterrupts() []string {
+	sort.SliceStable(f.interrupts, func(i, j int) bool {
+		return interruptNumber(f.interrupts[i]) < interruptNumber(f.interrupts[j])
+	})
+
+	var warnings []string
+	names := make(map[string]string)
+	for i, row := range f.interrupts {
+		if name, ok := names[row["value"]]; ok && name != row["name"] {
+			warnings = append(warnings, fmt.Sprintf("interrupt %s is shared by %q and %q", row["value"], name, row["name"]))
+		} else if !ok {
+			names[row["value"]] = row["name"]
 		}
+
+		f.assignID(row, interruptIDPrefix, i, row["_peripheral_name"]+"."+row["name"])
+		f.interruptChan <- row
+	}
+
+	return warnings
+}
+
+// interruptNumber returns the IRQ number of an interrupt row; unparsable values sort last.
+func interruptNumber(row map[string]string) int64 {
+	if value, err := ParseInterruptValue(row["value"]); err == nil {
+		return value
+	}
+	return int64(^uint64(0) >> 1)
+}
+
+// ParseInterruptValue parses an interrupt <value>: an SVD number, or a negative
+// decimal for core exceptions.
+func ParseInterruptValue(value string) (int64, error) {
+	if number, err := ParseSVDInt(value); err == nil {
+		return int64(number), nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// printWarnings prints each distinct warning once; elements copied by derivedFrom
+// or dim expansion would otherwise repeat the warnings of their source element.
+func printWarnings(warnings []string) {
+	seen := make(map[string]bool)
+	for _, warning := range warnings {
+		if seen[warning] {
+			continue
+		}
+		seen[warning] = true
+		fmt.Printf("Warning: %s\n", warning)
+	}
+}
+
+// leafValues collects the non-empty text children of an element, its derivedFrom
+// attribute and, for expanded dim instances, the array definition it came from.
+func leafValues(elem *Element) map[string]string {
+	row := make(map[string]string)
+	for _, c := range elem.Children {
+		if c.IsLeaf() && c.Text != "" {
+			row[c.Name] = c.Text
+		}
+	}
+	if ref := elem.Attr("derivedFrom"); ref != "" {
+		row["derivedFrom"] = ref
+	}
+	if elem.dimArray != "" {
+		row["_dim_array"] = elem.dimArray
+		row["_dim_index"] = elem.dimIndex
 	}
-	return false
+	return row
 }
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// accessCodes are the behavior codes of plain accesses without side effects.
+var accessCodes = map[string]string{
+	"read-only":      "RO",
+	"write-only":     "WO",
+	"read-write":     "RW",
+	"writeOnce":      "WO1",
+	"read-writeOnce": "RW1",
+}
+
+// writeCodes abbreviate modifiedWriteValues; "modify" is a plain write and has no code.
+var writeCodes = map[string]string{
+	"oneToClear":   "W1C",
+	"oneToSet":     "W1S",
+	"oneToToggle":  "W1T",
+	"zeroToClear":  "W0C",
+	"zeroToSet":    "W0S",
+	"zeroToToggle": "W0T",
+	"clear":        "WC",
+	"set":          "WS",
+}
+
+// readCodes abbreviate readAction side effects.
+var readCodes = map[string]string{
+	"clear":          "RC",
+	"set":            "RS",
+	"modify":         "RM",
+	"modifyExternal": "RME",
+}
+
+// behaviorCode classifies an access with its write and read side effects, e.g. "RW",
+// "RO", "W1C" (write one to clear), "RC" (clear on read) or "RC/W1C" for both.
+func behaviorCode(access, modifiedWriteValues, readAction string) string {
+	writeCode := writeCodes[modifiedWriteValues]
+	readCode := readCodes[readAction]
+
+	switch {
+	case readCode != "" && writeCode != "":
+		return readCode + "/" + writeCode
+	case writeCode != "":
+		return writeCode
+	case readCode != "" && access != "read-only":
+		return readCode + "/W"
+	case readCode != "":
+		return readCode
+	}
+	return accessCodes[access]
+}
+
+// writeConstraintText flattens a writeConstraint element into one value:
+// "writeAsRead", "useEnumeratedValues" or "range min-max".
+func writeConstraintText(elem *Element) string {
+	constraint := elem.Child("writeConstraint")
+	if constraint == nil {
+		return ""
+	}
+
+	switch {
+	case constraint.ChildText("writeAsRead") == "true" || constraint.ChildText("writeAsRead") == "1":
+		return "writeAsRead"
+	case constraint.ChildText("useEnumeratedValues") == "true" || constraint.ChildText("useEnumeratedValues") == "1":
+		return "useEnumeratedValues"
+	}
+	if r := constraint.Child("range"); r != nil {
+		return fmt.Sprintf("range %s-%s", r.ChildText("minimum"), r.ChildText("maximum"))
+	}
+	return ""
+}
+
+// WriteConstraintElement rebuilds the writeConstraint element from its flattened
+// value, or returns nil when the value is not recognized.
+func WriteConstraintElement(text string) *Element {
+	constraint := &Element{Name: "writeConstraint"}
+	switch text = strings.TrimSpace(text); text {
+	case "writeAsRead", "useEnumeratedValues":
+		constraint.SetChildText(text, "true")
+		return constraint
+	}
+
+	bounds, ok := strings.CutPrefix(text, "range ")
+	if !ok {
+		return nil
+	}
+	minimum, maximum, ok := strings.Cut(strings.TrimSpace(bounds), "-")
+	if !ok {
+		return nil
+	}
+	r := &Element{Name: "range"}
+	r.SetChildText("minimum", strings.TrimSpace(minimum))
+	r.SetChildText("maximum", strings.TrimSpace(maximum))
+	constraint.Children = append(constraint.Children, r)
+	return constraint
+}
+
+// applyBehavior sets the flattened writeConstraint and the _behavior classification
+// of a register or field row. Fields fall back to their register's write and read
+// side effects when they declare none.
+func applyBehavior(elem *Element, row map[string]string, registerRow map[string]string) {
+	if constraint := writeConstraintText(elem); constraint != "" {
+		row["writeConstraint"] = constraint
+	}
+
+	modifiedWriteValues, readAction := row["modifiedWriteValues"], row["readAction"]
+	if registerRow != nil {
+		if modifiedWriteValues == "" {
+			modifiedWriteValues = registerRow["modifiedWriteValues"]
+		}
+		if readAction == "" {
+			readAction = registerRow["readAction"]
+		}
+	}
+
+	if behavior := behaviorCode(row["_effective_access"], modifiedWriteValues, readAction); behavior != "" {
+		row["_behavior"] = behavior
+	}
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBehaviorCode(t *testing.T) {
+	tests := []struct {
+		access, modifiedWriteValues, readAction string
+		want                                    string
+	}{
+		{access: "read-write", want: "RW"},
+		{access: "read-only", want: "RO"},
+		{access: "write-only", want: "WO"},
+		{access: "writeOnce", want: "WO1"},
+		{access: "read-writeOnce", want: "RW1"},
+		{access: "", want: ""},
+		{access: "read-write", modifiedWriteValues: "modify", want: "RW"},
+		{access: "read-write", modifiedWriteValues: "oneToClear", want: "W1C"},
+		{access: "read-write", modifiedWriteValues: "oneToSet", want: "W1S"},
+		{access: "read-write", modifiedWriteValues: "oneToToggle", want: "W1T"},
+		{access: "read-write", modifiedWriteValues: "zeroToClear", want: "W0C"},
+		{access: "read-write", modifiedWriteValues: "zeroToSet", want: "W0S"},
+		{access: "read-write", modifiedWriteValues: "zeroToToggle", want: "W0T"},
+		{access: "write-only", modifiedWriteValues: "clear", want: "WC"},
+		{access: "write-only", modifiedWriteValues: "set", want: "WS"},
+		{access: "read-only", readAction: "clear", want: "RC"},
+		{access: "read-only", readAction: "set", want: "RS"},
+		{access: "read-only", readAction: "modify", want: "RM"},
+		{access: "read-only", readAction: "modifyExternal", want: "RME"},
+		{access: "read-write", readAction: "clear", want: "RC/W"},
+		{access: "", readAction: "clear", want: "RC/W"},
+		{access: "read-write", modifiedWriteValues: "oneToClear", readAction: "clear", want: "RC/W1C"},
+		{access: "read-write", modifiedWriteValues: "modify", readAction: "set", want: "RS/W"},
+		{access: "read-write", modifiedWriteValues: "unknown", readAction: "unknown", want: "RW"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.access+"/"+tt.modifiedWriteValues+"/"+tt.readAction, func(t *testing.T) {
+			if got := behaviorCode(tt.access, tt.modifiedWriteValues, tt.readAction); got != tt.want {
+				t.Errorf("behaviorCode(%q, %q, %q) = %q, want %q", tt.access, tt.modifiedWriteValues, tt.readAction, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteConstraintRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		want  string
+	}{
+		{
+			name:  "writeAsRead",
+			field: `<field><writeConstraint><writeAsRead>true</writeAsRead></writeConstraint></field>`,
+			want:  "writeAsRead",
+		},
+		{
+			name:  "writeAsRead as 1",
+			field: `<field><writeConstraint><writeAsRead>1</writeAsRead></writeConstraint></field>`,
+			want:  "writeAsRead",
+		},
+		{
+			name:  "useEnumeratedValues",
+			field: `<field><writeConstraint><useEnumeratedValues>true</useEnumeratedValues></writeConstraint></field>`,
+			want:  "useEnumeratedValues",
+		},
+		{
+			name:  "range",
+			field: `<field><writeConstraint><range><minimum>0</minimum><maximum>0x1F</maximum></range></writeConstraint></field>`,
+			want:  "range 0-0x1F",
+		},
+		{
+			name:  "false flags are no constraint",
+			field: `<field><writeConstraint><writeAsRead>false</writeAsRead></writeConstraint></field>`,
+			want:  "",
+		},
+		{
+			name:  "no writeConstraint",
+			field: `<field><name>F</name></field>`,
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := writeConstraintText(readTestSVD(t, tt.field))
+			if text != tt.want {
+				t.Fatalf("writeConstraintText = %q, want %q", text, tt.want)
+			}
+			if text == "" {
+				return
+			}
+
+			constraint := WriteConstraintElement(text)
+			if constraint == nil {
+				t.Fatalf("WriteConstraintElement(%q) = nil", text)
+			}
+			rebuilt := &Element{Name: "field", Children: []*Element{constraint}}
+			if again := writeConstraintText(rebuilt); again != text {
+				t.Errorf("round trip = %q, want %q", again, text)
+			}
+		})
+	}
+}
+
+func TestWriteConstraintElement(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "writeAsRead", want: []string{"writeAsRead=true"}},
+		{text: " useEnumeratedValues ", want: []string{"useEnumeratedValues=true"}},
+		{text: "range 0-7", want: []string{"minimum=0", "maximum=7"}},
+		{text: "range  2 - 0x10 ", want: []string{"minimum=2", "maximum=0x10"}},
+		{text: "range 7", want: nil},
+		{text: "0-7", want: nil},
+		{text: "readOnly", want: nil},
+		{text: "", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			constraint := WriteConstraintElement(tt.text)
+			if tt.want == nil {
+				if constraint != nil {
+					t.Errorf("WriteConstraintElement(%q) = %q, want nil", tt.text, leafChildren(constraint))
+				}
+				return
+			}
+			if constraint == nil {
+				t.Fatalf("WriteConstraintElement(%q) = nil, want %q", tt.text, tt.want)
+			}
+			got := leafChildren(constraint)
+			if r := constraint.Child("range"); r != nil {
+				got = leafChildren(r)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WriteConstraintElement(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyBehaviorInheritsRegisterSideEffects(t *testing.T) {
+	register := map[string]string{"modifiedWriteValues": "oneToClear", "readAction": "clear"}
+	tests := []struct {
+		name string
+		row  map[string]string
+		want string
+	}{
+		{
+			name: "field without side effects takes the register's",
+			row:  map[string]string{"_effective_access": "read-write"},
+			want: "RC/W1C",
+		},
+		{
+			name: "field side effects win",
+			row:  map[string]string{"_effective_access": "read-write", "modifiedWriteValues": "oneToSet", "readAction": "set"},
+			want: "RS/W1S",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyBehavior(&Element{Name: "field"}, tt.row, register)
+			if got := tt.row["_behavior"]; got != tt.want {
+				t.Errorf("_behavior = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
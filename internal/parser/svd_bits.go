@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bitPosition is a field position read from one of the three SVD notations.
+type bitPosition struct {
+	notation string
+	lsb      uint64
+	width    uint64
+	err      error
+}
+
+// fieldBitPositions reads every position notation a field declares:
+// bitOffset/bitWidth, lsb/msb and bitRange, in that order.
+func fieldBitPositions(field *Element) []bitPosition {
+	var positions []bitPosition
+
+	if offsetText := field.ChildText("bitOffset"); offsetText != "" {
+		position := bitPosition{notation: "bitOffset", width: 1}
+		offset, err := ParseSVDInt(offsetText)
+		if err != nil {
+			position.err = fmt.Errorf("invalid bitOffset %q", offsetText)
+		}
+		position.lsb = offset
+		if widthText := field.ChildText("bitWidth"); widthText != "" && position.err == nil {
+			if position.width, err = ParseSVDInt(widthText); err != nil || position.width == 0 {
+				position.err = fmt.Errorf("invalid bitWidth %q", widthText)
+			}
+		}
+		positions = append(positions, position)
+	}
+
+	if lsbText, msbText := field.ChildText("lsb"), field.ChildText("msb"); lsbText != "" || msbText != "" {
+		position := bitPosition{notation: "lsb/msb"}
+		lsb, lsbErr := ParseSVDInt(lsbText)
+		msb, msbErr := ParseSVDInt(msbText)
+		if lsbErr != nil || msbErr != nil || msb < lsb {
+			position.err = fmt.Errorf("invalid lsb/msb %q/%q", lsbText, msbText)
+		} else {
+			position.lsb, position.width = lsb, msb-lsb+1
+		}
+		positions = append(positions, position)
+	}
+
+	if bitRange := field.ChildText("bitRange"); bitRange != "" {
+		position := bitPosition{notation: "bitRange"}
+		msb, lsb, err := parseBitRange(bitRange)
+		if err != nil || msb < lsb {
+			position.err = fmt.Errorf("invalid bitRange %q", bitRange)
+		} else {
+			position.lsb, position.width = lsb, msb-lsb+1
+		}
+		positions = append(positions, position)
+	}
+
+	return positions
+}
+
+// fieldBitPosition returns a field's least significant bit and width from the first
+// valid notation it declares.
+func fieldBitPosition(field *Element) (uint64, uint64, error) {
+	positions := fieldBitPositions(field)
+	for _, position := range positions {
+		if position.err == nil {
+			return position.lsb, position.width, nil
+		}
+	}
+	if len(positions) > 0 {
+		return 0, 0, positions[0].err
+	}
+	return 0, 0, fmt.Errorf("no bit position")
+}
+
+// bitPositionConflict describes invalid or disagreeing position notations of a field,
+// or returns "" when all declared notations agree.
+func bitPositionConflict(positions []bitPosition) string {
+	var problems []string
+	var reference *bitPosition
+	for i := range positions {
+		position := &positions[i]
+		if position.err != nil {
+			problems = append(problems, position.err.Error())
+			continue
+		}
+		if reference == nil {
+			reference = position
+			continue
+		}
+		if position.lsb != reference.lsb || position.width != reference.width {
+			problems = append(problems, fmt.Sprintf("%s [%d:%d] disagrees with %s [%d:%d]",
+				position.notation, position.lsb+position.width-1, position.lsb,
+				reference.notation, reference.lsb+reference.width-1, reference.lsb))
+		}
+	}
+	return strings.Join(problems, "; ")
+}
+
+// bitMask returns the mask covering width bits starting at lsb.
+func bitMask(lsb, width uint64) uint64 {
+	if width >= 64 {
+		return ^uint64(0) << lsb
+	}
+	return ((uint64(1) << width) - 1) << lsb
+}
@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParseBitRange(t *testing.T) {
+	tests := []struct {
+		bitRange string
+		msb, lsb uint64
+		wantErr  bool
+	}{
+		{bitRange: "[7:4]", msb: 7, lsb: 4},
+		{bitRange: "[31:0]", msb: 31, lsb: 0},
+		{bitRange: "[0x7:4]", msb: 7, lsb: 4},
+		{bitRange: " [ 7 : 4 ] ", msb: 7, lsb: 4},
+		{bitRange: "[3:3]", msb: 3, lsb: 3},
+		// Inverted ranges parse; fieldBitPositions rejects them.
+		{bitRange: "[4:7]", msb: 4, lsb: 7},
+		{bitRange: "[7-4]", wantErr: true},
+		{bitRange: "[7:]", wantErr: true},
+		{bitRange: "[:4]", wantErr: true},
+		{bitRange: "[7:4:0]", wantErr: true},
+		{bitRange: "[x:4]", wantErr: true},
+		{bitRange: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.bitRange, func(t *testing.T) {
+			msb, lsb, err := parseBitRange(tt.bitRange)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseBitRange(%q) = %d, %d, want an error", tt.bitRange, msb, lsb)
+				}
+				return
+			}
+			if err != nil || msb != tt.msb || lsb != tt.lsb {
+				t.Errorf("parseBitRange(%q) = %d, %d, %v, want %d, %d", tt.bitRange, msb, lsb, err, tt.msb, tt.lsb)
+			}
+		})
+	}
+}
+
+func TestFieldBitPosition(t *testing.T) {
+	tests := []struct {
+		name       string
+		field      string
+		lsb, width uint64
+		wantErr    bool
+		conflict   string
+	}{
+		{
+			name:  "bitOffset and bitWidth",
+			field: `<field><bitOffset>4</bitOffset><bitWidth>3</bitWidth></field>`,
+			lsb:   4, width: 3,
+		},
+		{
+			name:  "bitOffset without bitWidth is one bit",
+			field: `<field><bitOffset>0x1F</bitOffset></field>`,
+			lsb:   31, width: 1,
+		},
+		{
+			name:  "lsb and msb",
+			field: `<field><lsb>8</lsb><msb>15</msb></field>`,
+			lsb:   8, width: 8,
+		},
+		{
+			name:  "bitRange",
+			field: `<field><bitRange>[7:4]</bitRange></field>`,
+			lsb:   4, width: 4,
+		},
+		{
+			name:  "single-bit bitRange",
+			field: `<field><bitRange>[0:0]</bitRange></field>`,
+			lsb:   0, width: 1,
+		},
+		{
+			name:     "invalid bitOffset",
+			field:    `<field><bitOffset>four</bitOffset></field>`,
+			wantErr:  true,
+			conflict: `invalid bitOffset "four"`,
+		},
+		{
+			name:     "zero bitWidth",
+			field:    `<field><bitOffset>4</bitOffset><bitWidth>0</bitWidth></field>`,
+			wantErr:  true,
+			conflict: `invalid bitWidth "0"`,
+		},
+		{
+			name:     "lsb without msb",
+			field:    `<field><lsb>8</lsb></field>`,
+			wantErr:  true,
+			conflict: `invalid lsb/msb "8"/""`,
+		},
+		{
+			name:     "inverted lsb/msb",
+			field:    `<field><lsb>15</lsb><msb>8</msb></field>`,
+			wantErr:  true,
+			conflict: `invalid lsb/msb "15"/"8"`,
+		},
+		{
+			name:     "inverted bitRange",
+			field:    `<field><bitRange>[4:7]</bitRange></field>`,
+			wantErr:  true,
+			conflict: `invalid bitRange "[4:7]"`,
+		},
+		{
+			name:     "malformed bitRange",
+			field:    `<field><bitRange>7:4:0</bitRange></field>`,
+			wantErr:  true,
+			conflict: `invalid bitRange "7:4:0"`,
+		},
+		{
+			name:    "no position",
+			field:   `<field><name>F</name></field>`,
+			wantErr: true,
+		},
+		{
+			name:  "agreeing notations",
+			field: `<field><bitOffset>4</bitOffset><bitWidth>4</bitWidth><lsb>4</lsb><msb>7</msb><bitRange>[7:4]</bitRange></field>`,
+			lsb:   4, width: 4,
+		},
+		{
+			name:     "conflicting notations use the first one",
+			field:    `<field><bitOffset>4</bitOffset><bitWidth>2</bitWidth><bitRange>[7:4]</bitRange></field>`,
+			lsb:      4,
+			width:    2,
+			conflict: "bitRange [7:4] disagrees with bitOffset [5:4]",
+		},
+		{
+			name:     "lsb/msb disagrees with bitOffset",
+			field:    `<field><bitOffset>0</bitOffset><lsb>1</lsb><msb>1</msb></field>`,
+			lsb:      0,
+			width:    1,
+			conflict: "lsb/msb [1:1] disagrees with bitOffset [0:0]",
+		},
+		{
+			name:     "invalid first notation falls back to the next",
+			field:    `<field><bitOffset>x</bitOffset><bitRange>[3:2]</bitRange></field>`,
+			lsb:      2,
+			width:    2,
+			conflict: `invalid bitOffset "x"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := readTestSVD(t, tt.field)
+			lsb, width, err := fieldBitPosition(field)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("fieldBitPosition = %d, %d, want an error", lsb, width)
+				}
+			} else if err != nil || lsb != tt.lsb || width != tt.width {
+				t.Errorf("fieldBitPosition = %d, %d, %v, want %d, %d", lsb, width, err, tt.lsb, tt.width)
+			}
+			if conflict := bitPositionConflict(fieldBitPositions(field)); conflict != tt.conflict {
+				t.Errorf("bitPositionConflict = %q, want %q", conflict, tt.conflict)
+			}
+		})
+	}
+}
+
+func TestBitMask(t *testing.T) {
+	tests := []struct {
+		lsb, width uint64
+		want       uint64
+	}{
+		{lsb: 0, width: 1, want: 0x1},
+		{lsb: 4, width: 4, want: 0xF0},
+		{lsb: 0, width: 32, want: 0xFFFFFFFF},
+		{lsb: 32, width: 32, want: 0xFFFFFFFF00000000},
+		{lsb: 0, width: 64, want: ^uint64(0)},
+	}
+	for _, tt := range tests {
+		if got := bitMask(tt.lsb, tt.width); got != tt.want {
+			t.Errorf("bitMask(%d, %d) = %#x, want %#x", tt.lsb, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// derivableElements lists the SVD elements that accept a derivedFrom attribute.
+var derivableElements = map[string]bool{
+	"peripheral":       true,
+	"cluster":          true,
+	"register":         true,
+	"field":            true,
+	"enumeratedValues": true,
+}
+
+// derivedContainers are merged item by item, so a derived element can override single entries.
+var derivedContainers = map[string]bool{
+	"registers": true,
+	"fields":    true,
+}
+
+// derivedRepeatables are replaced as a whole when the derived element declares its own.
+var derivedRepeatables = map[string]bool{
+	"addressBlock":     true,
+	"interrupt":        true,
+	"enumeratedValues": true,
+	"enumeratedValue":  true,
+}
+
+// derivedGroups are elements that together describe one property. A derived element
+// that declares any member of a group inherits none of the base's members, so a field
+// positioned with bitOffset does not also keep its base's bitRange; members it leaves
+// out are then completed from the base's values (see completePosition and completeDim).
+var derivedGroups = map[string]string{
+	"bitOffset":    "position",
+	"bitWidth":     "position",
+	"lsb":          "position",
+	"msb":          "position",
+	"bitRange":     "position",
+	"dim":          "dim",
+	"dimIncrement": "dim",
+	"dimIndex":     "dim",
+}
+
+// deriveResolver applies derivedFrom inheritance to an SVD element tree.
+type deriveResolver struct {
+	device   *Element
+	parents  map[*Element]*Element
+	visiting map[*Element]bool
+	done     map[*Element]bool
+	warnings []string
+}
+
+// resolveDerivedFrom replaces the children of every derived element with the children
+// of its base element, with the derived element's own children applied as overrides.
+// The derivedFrom attribute is kept so the relationship stays visible in the output.
+func resolveDerivedFrom(device *Element) []string {
+	r := &deriveResolver{
+		device:   device,
+		parents:  make(map[*Element]*Element),
+		visiting: make(map[*Element]bool),
+		done:     make(map[*Element]bool),
+	}
+	r.indexParents(device)
+	r.resolveChildren(device)
+	return r.warnings
+}
+
+func (r *deriveResolver) indexParents(elem *Element) {
+	for _, c := range elem.Children {
+		r.parents[c] = elem
+		r.indexParents(c)
+	}
+}
+
+func (r *deriveResolver) resolveChildren(elem *Element) {
+	for _, c := range elem.Children {
+		r.resolve(c)
+	}
+}
+
+func (r *deriveResolver) resolve(elem *Element) {
+	if r.done[elem] {
+		return
+	}
+	if r.visiting[elem] {
+		r.warn(fmt.Sprintf("circular derivedFrom reference at %s %q", elem.Name, elem.ChildText("name")))
+		return
+	}
+	r.visiting[elem] = true
+
+	if ref := elem.Attr("derivedFrom"); ref != "" && derivableElements[elem.Name] {
+		base := r.findBase(elem, ref)
+		if base == nil {
+			r.warn(fmt.Sprintf("%s %q: derivedFrom %q not found", elem.Name, elem.ChildText("name"), ref))
+		} else {
+			r.resolve(base)
+			elem.Children = mergeDerived(base, elem).Children
+			r.indexParents(elem)
+		}
+	}
+
+	delete(r.visiting, elem)
+	r.done[elem] = true
+	r.resolveChildren(elem)
+}
+
+func (r *deriveResolver) warn(message string) {
+	r.warnings = append(r.warnings, message)
+}
+
+// findBase looks up a derivedFrom reference among the element's siblings first,
+// then as a dotted path from the device (e.g. "TIM1.CR1.CEN"). Unqualified
+// enumeratedValues references are searched in the enclosing register, peripheral and device.
+func (r *deriveResolver) findBase(elem *Element, ref string) *Element {
+	if parent := r.parents[elem]; parent != nil && !strings.Contains(ref, ".") {
+		for _, sibling := range parent.Children {
+			if sibling != elem && sibling.Name == elem.Name && sibling.ChildText("name") == ref {
+				return sibling
+			}
+		}
+	}
+
+	if elem.Name == "enumeratedValues" && !strings.Contains(ref, ".") {
+		for scope := r.parents[elem]; scope != nil; scope = r.parents[scope] {
+			if base := findEnumeratedValues(scope, ref, elem); base != nil {
+				return base
+			}
+		}
+		return nil
+	}
+
+	base := findElementByPath(r.device, strings.Split(ref, "."))
+	if base == elem || (base != nil && base.Name != elem.Name) {
+		return nil
+	}
+	return base
+}
+
+// findEnumeratedValues searches the subtree of scope for an enumeratedValues set with the given name.
+func findEnumeratedValues(scope *Element, name string, exclude *Element) *Element {
+	for _, c := range scope.Children {
+		if c.Name == "enumeratedValues" && c != exclude && c.ChildText("name") == name {
+			return c
+		}
+		if found := findEnumeratedValues(c, name, exclude); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findElementByPath walks named SVD elements from the device, one path segment per level.
+func findElementByPath(device *Element, path []string) *Element {
+	current := device
+	for _, name := range path {
+		var next *Element
+		for _, c := range namedChildren(current) {
+			if c.ChildText("name") == name {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// namedChildren returns the named SVD elements nested one level below elem,
+// skipping the peripherals/registers/fields wrapper elements.
+func namedChildren(elem *Element) []*Element {
+	var result []*Element
+	switch elem.Name {
+	case "device":
+		if peripherals := elem.Child("peripherals"); peripherals != nil {
+			result = peripherals.ChildrenNamed("peripheral")
+		}
+	case "peripheral":
+		if registers := elem.Child("registers"); registers != nil {
+			result = registerItems(registers)
+		}
+	case "cluster":
+		result = registerItems(elem)
+	case "register":
+		if fields := elem.Child("fields"); fields != nil {
+			result = fields.ChildrenNamed("field")
+		}
+	case "field":
+		result = elem.ChildrenNamed("enumeratedValues")
+	}
+	return result
+}
+
+// registerItems returns the register and cluster children of a registers or cluster element.
+func registerItems(elem *Element) []*Element {
+	var result []*Element
+	for _, c := range elem.Children {
+		if c.Name == "register" || c.Name == "cluster" {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// mergeDerived returns a copy of base with the children of local applied on top.
+func mergeDerived(base, local *Element) *Element {
+	merged := base.Clone()
+	merged.RemoveChildren("interrupt")
+
+	localGroups := make(map[string]bool)
+	for _, c := range local.Children {
+		if group, ok := derivedGroups[c.Name]; ok {
+			localGroups[group] = true
+		}
+	}
+	inherited := merged.Children[:0]
+	for _, c := range merged.Children {
+		if !localGroups[derivedGroups[c.Name]] {
+			inherited = append(inherited, c)
+		}
+	}
+	merged.Children = inherited
+
+	replaced := make(map[string]bool)
+	for _, c := range local.Children {
+		switch {
+		case derivedContainers[c.Name]:
+			mergeContainer(merged, c)
+		case derivedRepeatables[c.Name]:
+			if !replaced[c.Name] {
+				merged.RemoveChildren(c.Name)
+				replaced[c.Name] = true
+			}
+			merged.Children = append(merged.Children, c.Clone())
+		default:
+			replaceChild(merged, c.Clone())
+		}
+	}
+
+	if localGroups["position"] {
+		completePosition(merged, base)
+	}
+	if localGroups["dim"] {
+		completeDim(merged, base)
+	}
+	return merged
+}
+
+// completePosition adds the half of a bitOffset/bitWidth or lsb/msb pair the derived
+// field leaves out, keeping the base field's offset or width.
+func completePosition(merged, base *Element) {
+	lsb, width, err := fieldBitPosition(base)
+	if err != nil {
+		return
+	}
+	offsetText, widthText := merged.ChildText("bitOffset"), merged.ChildText("bitWidth")
+	switch {
+	case offsetText != "" && widthText == "":
+		merged.SetChildText("bitWidth", strconv.FormatUint(width, 10))
+	case offsetText == "" && widthText != "":
+		merged.SetChildText("bitOffset", strconv.FormatUint(lsb, 10))
+	}
+
+	lsbText, msbText := merged.ChildText("lsb"), merged.ChildText("msb")
+	switch {
+	case lsbText != "" && msbText == "":
+		if value, err := ParseSVDInt(lsbText); err == nil {
+			merged.SetChildText("msb", strconv.FormatUint(value+width-1, 10))
+		}
+	case lsbText == "" && msbText != "":
+		if value, err := ParseSVDInt(msbText); err == nil && value+1 >= width {
+			merged.SetChildText("lsb", strconv.FormatUint(value+1-width, 10))
+		}
+	}
+}
+
+// completeDim inherits the dim and dimIncrement the derived element leaves out, and
+// the base's dimIndex only when the element count is unchanged.
+func completeDim(merged, base *Element) {
+	for _, name := range []string{"dim", "dimIncrement"} {
+		if merged.Child(name) == nil && base.Child(name) != nil {
+			merged.SetChildText(name, base.ChildText(name))
+		}
+	}
+	if merged.Child("dimIndex") == nil && base.Child("dimIndex") != nil &&
+		strings.TrimSpace(merged.ChildText("dim")) == strings.TrimSpace(base.ChildText("dim")) {
+		merged.SetChildText("dimIndex", base.ChildText("dimIndex"))
+	}
+}
+
+// mergeContainer merges the items of a registers or fields element by name.
+func mergeContainer(merged, local *Element) {
+	container := merged.Child(local.Name)
+	if container == nil {
+		merged.Children = append(merged.Children, local.Clone())
+		return
+	}
+
+	for _, item := range local.Children {
+		name := item.ChildText("name")
+		replacedItem := false
+		for i, existing := range container.Children {
+			if existing.Name == item.Name && existing.ChildText("name") == name {
+				container.Children[i] = item.Clone()
+				replacedItem = true
+				break
+			}
+		}
+		if !replacedItem {
+			container.Children = append(container.Children, item.Clone())
+		}
+	}
+}
+
+// replaceChild replaces the first child with the same name, appending it if absent.
+func replaceChild(elem, child *Element) {
+	for i, c := range elem.Children {
+		if c.Name == child.Name {
+			elem.Children[i] = child
+			return
+		}
+	}
+	elem.Children = append(elem.Children, child)
+}
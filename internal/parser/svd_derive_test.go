@@ -0,0 +1,210 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// leafChildren returns the name=text pairs of an element's leaf children in order.
+func leafChildren(elem *Element) []string {
+	var leaves []string
+	for _, c := range elem.Children {
+		if c.IsLeaf() {
+			leaves = append(leaves, c.Name+"="+strings.TrimSpace(c.Text))
+		}
+	}
+	return leaves
+}
+
+func TestMergeDerivedField(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  string
+		local string
+		want  []string
+	}{
+		{
+			name:  "local elements override, others are inherited",
+			base:  `<name>EN</name><description>Enable</description><bitOffset>0</bitOffset><bitWidth>1</bitWidth><access>read-write</access>`,
+			local: `<name>EN2</name><access>read-only</access>`,
+			want:  []string{"name=EN2", "description=Enable", "bitOffset=0", "bitWidth=1", "access=read-only"},
+		},
+		{
+			name:  "bitOffset replaces an inherited bitRange",
+			base:  `<name>EN</name><bitRange>[0:0]</bitRange>`,
+			local: `<name>EN2</name><bitOffset>2</bitOffset><bitWidth>1</bitWidth>`,
+			want:  []string{"name=EN2", "bitOffset=2", "bitWidth=1"},
+		},
+		{
+			name:  "bitRange replaces inherited bitOffset and bitWidth",
+			base:  `<name>MODE</name><bitOffset>0</bitOffset><bitWidth>2</bitWidth>`,
+			local: `<name>MODE1</name><bitRange>[3:2]</bitRange>`,
+			want:  []string{"name=MODE1", "bitRange=[3:2]"},
+		},
+		{
+			name:  "lsb/msb replace inherited bitRange",
+			base:  `<name>MODE</name><bitRange>[1:0]</bitRange>`,
+			local: `<name>MODE1</name><lsb>2</lsb><msb>3</msb>`,
+			want:  []string{"name=MODE1", "lsb=2", "msb=3"},
+		},
+		{
+			name:  "bitOffset alone keeps the base width",
+			base:  `<name>MODE</name><bitOffset>0</bitOffset><bitWidth>2</bitWidth>`,
+			local: `<name>MODE1</name><bitOffset>2</bitOffset>`,
+			want:  []string{"name=MODE1", "bitOffset=2", "bitWidth=2"},
+		},
+		{
+			name:  "bitOffset alone keeps the width of a base bitRange",
+			base:  `<name>MODE</name><bitRange>[1:0]</bitRange>`,
+			local: `<name>MODE1</name><bitOffset>4</bitOffset>`,
+			want:  []string{"name=MODE1", "bitOffset=4", "bitWidth=2"},
+		},
+		{
+			name:  "bitWidth alone keeps the base offset",
+			base:  `<name>MODE</name><lsb>4</lsb><msb>4</msb>`,
+			local: `<name>MODE1</name><bitWidth>3</bitWidth>`,
+			want:  []string{"name=MODE1", "bitWidth=3", "bitOffset=4"},
+		},
+		{
+			name:  "lsb alone keeps the base width",
+			base:  `<name>MODE</name><bitOffset>0</bitOffset><bitWidth>2</bitWidth>`,
+			local: `<name>MODE1</name><lsb>6</lsb>`,
+			want:  []string{"name=MODE1", "lsb=6", "msb=7"},
+		},
+		{
+			name:  "msb alone keeps the base width",
+			base:  `<name>MODE</name><bitRange>[1:0]</bitRange>`,
+			local: `<name>MODE1</name><msb>7</msb>`,
+			want:  []string{"name=MODE1", "msb=7", "lsb=6"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := readTestSVD(t, "<field>"+tt.base+"</field>")
+			local := readTestSVD(t, `<field derivedFrom="X">`+tt.local+"</field>")
+
+			merged := mergeDerived(base, local)
+			if got := leafChildren(merged); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged = %q, want %q", got, tt.want)
+			}
+			if conflict := bitPositionConflict(fieldBitPositions(merged)); conflict != "" {
+				t.Errorf("position conflict %q", conflict)
+			}
+		})
+	}
+}
+
+func TestMergeDerivedDim(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  string
+		local string
+		want  []string
+	}{
+		{
+			name:  "dim arrays are inherited whole",
+			base:  `<dim>4</dim><dimIncrement>4</dimIncrement><dimIndex>A-D</dimIndex><name>CH[%s]</name>`,
+			local: `<name>CH[%s]</name><description>Copy</description>`,
+			want:  []string{"dim=4", "dimIncrement=4", "dimIndex=A-D", "name=CH[%s]", "description=Copy"},
+		},
+		{
+			name:  "a new dim drops the inherited dimIndex",
+			base:  `<dim>4</dim><dimIncrement>4</dimIncrement><dimIndex>A-D</dimIndex><name>CH%s</name>`,
+			local: `<dim>2</dim><name>CH%s</name>`,
+			want:  []string{"name=CH%s", "dim=2", "dimIncrement=4"},
+		},
+		{
+			name:  "the same dim keeps the inherited dimIndex",
+			base:  `<dim>4</dim><dimIncrement>4</dimIncrement><dimIndex>A-D</dimIndex><name>CH%s</name>`,
+			local: `<dim>4</dim><dimIncrement>8</dimIncrement><name>CH%s</name>`,
+			want:  []string{"name=CH%s", "dim=4", "dimIncrement=8", "dimIndex=A-D"},
+		},
+		{
+			name:  "a local dimIndex replaces the inherited one",
+			base:  `<dim>2</dim><dimIncrement>4</dimIncrement><dimIndex>0,1</dimIndex><name>CH%s</name>`,
+			local: `<dimIndex>X,Y</dimIndex><name>CH%s</name>`,
+			want:  []string{"name=CH%s", "dimIndex=X,Y", "dim=2", "dimIncrement=4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := readTestSVD(t, "<register>"+tt.base+"</register>")
+			local := readTestSVD(t, `<register derivedFrom="X">`+tt.local+"</register>")
+
+			if got := leafChildren(mergeDerived(base, local)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveDerivedFrom(t *testing.T) {
+	device := readTestSVD(t, `<device>
+  <peripherals>
+    <peripheral>
+      <name>TIM1</name>
+      <description>Timer</description>
+      <baseAddress>0x40010000</baseAddress>
+      <addressBlock><offset>0</offset><size>0x400</size><usage>registers</usage></addressBlock>
+      <interrupt><name>TIM1</name><value>25</value></interrupt>
+      <registers>
+        <register>
+          <name>CR1</name>
+          <addressOffset>0x0</addressOffset>
+          <fields>
+            <field><name>CEN</name><bitOffset>0</bitOffset><bitWidth>1</bitWidth></field>
+            <field><name>DIR</name><bitOffset>4</bitOffset><bitWidth>1</bitWidth></field>
+          </fields>
+        </register>
+        <register><name>SR</name><addressOffset>0x10</addressOffset></register>
+      </registers>
+    </peripheral>
+    <peripheral derivedFrom="TIM1">
+      <name>TIM8</name>
+      <baseAddress>0x40010400</baseAddress>
+      <registers>
+        <register>
+          <name>CR1</name>
+          <addressOffset>0x0</addressOffset>
+          <fields><field derivedFrom="TIM1.CR1.CEN"><name>CEN</name><description>Counter enable</description></field></fields>
+        </register>
+      </registers>
+    </peripheral>
+    <peripheral derivedFrom="NOPE"><name>TIM9</name></peripheral>
+  </peripherals>
+</device>`)
+
+	warnings := resolveDerivedFrom(device)
+	if want := []string{`peripheral "TIM9": derivedFrom "NOPE" not found`}; !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+
+	tim8 := device.Child("peripherals").ChildrenNamed("peripheral")[1]
+	if got := leafChildren(tim8); !reflect.DeepEqual(got, []string{"name=TIM8", "description=Timer", "baseAddress=0x40010400"}) {
+		t.Errorf("TIM8 = %q", got)
+	}
+	if tim8.Child("interrupt") != nil {
+		t.Error("TIM8 inherited the TIM1 interrupt")
+	}
+	if tim8.Child("addressBlock") == nil {
+		t.Error("TIM8 did not inherit the addressBlock")
+	}
+
+	var registers []string
+	for _, register := range tim8.Child("registers").ChildrenNamed("register") {
+		registers = append(registers, register.ChildText("name"))
+	}
+	if !reflect.DeepEqual(registers, []string{"CR1", "SR"}) {
+		t.Errorf("TIM8 registers = %q, want [CR1 SR]", registers)
+	}
+
+	fields := findRegister(device, "TIM8", "CR1").Child("fields").ChildrenNamed("field")
+	if len(fields) != 1 {
+		t.Fatalf("TIM8 CR1 has %d fields, want only the local CEN", len(fields))
+	}
+	want := []string{"name=CEN", "bitOffset=0", "bitWidth=1", "description=Counter enable"}
+	if got := leafChildren(fields[0]); !reflect.DeepEqual(got, want) {
+		t.Errorf("TIM8 CR1.CEN = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,225 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dimElements lists the SVD elements that may be declared as dim arrays.
+var dimElements = map[string]bool{
+	"peripheral": true,
+	"cluster":    true,
+	"register":   true,
+	"field":      true,
+}
+
+// dimChildren are the array declaration elements removed from expanded instances.
+var dimChildren = []string{"dim", "dimIncrement", "dimIndex", "dimName", "dimArrayIndex"}
+
+// expandDimArrays replaces every element declared with <dim> by one concrete instance
+// per index, substituting the %s placeholder and shifting offsets by dimIncrement.
+func expandDimArrays(elem *Element) []string {
+	var warnings []string
+
+	expanded := make([]*Element, 0, len(elem.Children))
+	for _, c := range elem.Children {
+		if !dimElements[c.Name] || c.Child("dim") == nil {
+			expanded = append(expanded, c)
+			continue
+		}
+
+		instances, err := expandDimElement(c)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s %q: %v", c.Name, c.ChildText("name"), err))
+			expanded = append(expanded, c)
+			continue
+		}
+		expanded = append(expanded, instances...)
+	}
+	elem.Children = expanded
+
+	for _, c := range elem.Children {
+		warnings = append(warnings, expandDimArrays(c)...)
+	}
+
+	return warnings
+}
+
+// expandDimElement builds the instances of a single dim array element.
+func expandDimElement(elem *Element) ([]*Element, error) {
+	dim, err := ParseSVDInt(elem.ChildText("dim"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid dim: %w", err)
+	}
+
+	increment, err := ParseSVDInt(elem.ChildText("dimIncrement"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid dimIncrement: %w", err)
+	}
+
+	name := elem.ChildText("name")
+	if !strings.Contains(name, "%s") {
+		return nil, fmt.Errorf("dim array name has no %%s placeholder")
+	}
+
+	var indices []string
+	if strings.Contains(name, "[%s]") {
+		indices = defaultDimIndices(int(dim))
+	} else {
+		indices, err = parseDimIndex(elem.ChildText("dimIndex"), int(dim))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	instances := make([]*Element, 0, len(indices))
+	for i, index := range indices {
+		instance := elem.Clone()
+		for _, child := range dimChildren {
+			instance.RemoveChildren(child)
+		}
+
+		for _, child := range []string{"name", "displayName", "description"} {
+			if c := instance.Child(child); c != nil {
+				c.Text = strings.ReplaceAll(c.Text, "%s", index)
+			}
+		}
+
+		if err := shiftDimInstance(instance, uint64(i)*increment); err != nil {
+			return nil, err
+		}
+
+		instance.dimArray = name
+		instance.dimIndex = index
+		instances = append(instances, instance)
+	}
+
+	return instances, nil
+}
+
+// shiftDimInstance moves an instance by its offset: bytes for peripherals, clusters
+// and registers, bits for fields.
+func shiftDimInstance(instance *Element, offset uint64) error {
+	switch instance.Name {
+	case "peripheral":
+		return shiftChildValue(instance, "baseAddress", offset)
+	case "cluster", "register":
+		return shiftChildValue(instance, "addressOffset", offset)
+	case "field":
+		for _, child := range []string{"bitOffset", "lsb", "msb"} {
+			if instance.Child(child) == nil {
+				continue
+			}
+			if err := shiftChildValue(instance, child, offset); err != nil {
+				return err
+			}
+		}
+		if c := instance.Child("bitRange"); c != nil {
+			msb, lsb, err := parseBitRange(c.Text)
+			if err != nil {
+				return err
+			}
+			c.Text = fmt.Sprintf("[%d:%d]", msb+offset, lsb+offset)
+		}
+	}
+	return nil
+}
+
+// shiftChildValue adds offset to a numeric child element, keeping hex values in hex.
+func shiftChildValue(elem *Element, child string, offset uint64) error {
+	c := elem.Child(child)
+	if c == nil || offset == 0 {
+		return nil
+	}
+
+	value, err := ParseSVDInt(c.Text)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", child, err)
+	}
+
+	if strings.HasPrefix(strings.ToLower(c.Text), "0x") {
+		c.Text = formatHex(value + offset)
+	} else {
+		c.Text = strconv.FormatUint(value+offset, 10)
+	}
+	return nil
+}
+
+// parseBitRange parses a field bitRange in the form [msb:lsb].
+func parseBitRange(bitRange string) (uint64, uint64, error) {
+	inner := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(bitRange), "["), "]")
+	parts := strings.Split(inner, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid bitRange %q", bitRange)
+	}
+
+	msb, err := ParseSVDInt(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid bitRange %q", bitRange)
+	}
+	lsb, err := ParseSVDInt(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid bitRange %q", bitRange)
+	}
+
+	return msb, lsb, nil
+}
+
+// parseDimIndex expands a dimIndex list ("A,B,C") or range ("0-3", "A-D").
+func parseDimIndex(dimIndex string, dim int) ([]string, error) {
+	dimIndex = strings.TrimSpace(dimIndex)
+	if dimIndex == "" {
+		return defaultDimIndices(dim), nil
+	}
+
+	var indices []string
+	if from, to, ok := strings.Cut(dimIndex, "-"); ok && !strings.Contains(dimIndex, ",") {
+		indices = expandDimRange(strings.TrimSpace(from), strings.TrimSpace(to))
+		if indices == nil {
+			return nil, fmt.Errorf("invalid dimIndex range %q", dimIndex)
+		}
+	} else {
+		for _, index := range strings.Split(dimIndex, ",") {
+			indices = append(indices, strings.TrimSpace(index))
+		}
+	}
+
+	if len(indices) != dim {
+		return nil, fmt.Errorf("dimIndex %q has %d entries, expected dim %d", dimIndex, len(indices), dim)
+	}
+	return indices, nil
+}
+
+// expandDimRange expands a numeric or single-letter dimIndex range.
+func expandDimRange(from, to string) []string {
+	var indices []string
+
+	if start, err := strconv.Atoi(from); err == nil {
+		end, err := strconv.Atoi(to)
+		if err != nil || end < start {
+			return nil
+		}
+		for i := start; i <= end; i++ {
+			indices = append(indices, strconv.Itoa(i))
+		}
+		return indices
+	}
+
+	if len(from) == 1 && len(to) == 1 && from[0] <= to[0] {
+		for c := from[0]; c <= to[0]; c++ {
+			indices = append(indices, string(c))
+		}
+		return indices
+	}
+
+	return nil
+}
+
+func defaultDimIndices(dim int) []string {
+	indices := make([]string, dim)
+	for i := range indices {
+		indices[i] = strconv.Itoa(i)
+	}
+	return indices
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDimIndex(t *testing.T) {
+	tests := []struct {
+		dimIndex string
+		dim      int
+		want     []string
+		wantErr  bool
+	}{
+		{dimIndex: "", dim: 3, want: []string{"0", "1", "2"}},
+		{dimIndex: "0-3", dim: 4, want: []string{"0", "1", "2", "3"}},
+		{dimIndex: "4-6", dim: 3, want: []string{"4", "5", "6"}},
+		{dimIndex: " 1 - 2 ", dim: 2, want: []string{"1", "2"}},
+		{dimIndex: "7-7", dim: 1, want: []string{"7"}},
+		{dimIndex: "A-D", dim: 4, want: []string{"A", "B", "C", "D"}},
+		{dimIndex: "x-z", dim: 3, want: []string{"x", "y", "z"}},
+		{dimIndex: "A,B,C", dim: 3, want: []string{"A", "B", "C"}},
+		{dimIndex: " TX , RX ", dim: 2, want: []string{"TX", "RX"}},
+		{dimIndex: "1,3,8", dim: 3, want: []string{"1", "3", "8"}},
+		{dimIndex: "A-1,B-2", dim: 2, want: []string{"A-1", "B-2"}},
+		{dimIndex: "3-0", dim: 4, wantErr: true},
+		{dimIndex: "D-A", dim: 4, wantErr: true},
+		{dimIndex: "A-3", dim: 4, wantErr: true},
+		{dimIndex: "AB-CD", dim: 2, wantErr: true},
+		{dimIndex: "0-", dim: 1, wantErr: true},
+		{dimIndex: "0-3", dim: 3, wantErr: true},
+		{dimIndex: "A,B", dim: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dimIndex, func(t *testing.T) {
+			got, err := parseDimIndex(tt.dimIndex, tt.dim)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseDimIndex(%q, %d) = %q, want an error", tt.dimIndex, tt.dim, got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDimIndex(%q, %d) = %q, %v, want %q", tt.dimIndex, tt.dim, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandDimElement(t *testing.T) {
+	tests := []struct {
+		name    string
+		elem    string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name: "register list keeps hex offsets in hex",
+			elem: `<register><dim>2</dim><dimIncrement>0x4</dimIncrement><dimIndex>TX,RX</dimIndex>
+				<name>%sDR</name><description>%s data</description><addressOffset>0x10</addressOffset></register>`,
+			want: [][]string{
+				{"name=TXDR", "description=TX data", "addressOffset=0x10"},
+				{"name=RXDR", "description=RX data", "addressOffset=0x14"},
+			},
+		},
+		{
+			name: "array names ignore dimIndex",
+			elem: `<register><dim>2</dim><dimIncrement>4</dimIncrement><dimIndex>A-B</dimIndex>
+				<name>BUF[%s]</name><addressOffset>8</addressOffset></register>`,
+			want: [][]string{
+				{"name=BUF[0]", "addressOffset=8"},
+				{"name=BUF[1]", "addressOffset=12"},
+			},
+		},
+		{
+			name: "field instances shift every position notation",
+			elem: `<field><dim>2</dim><dimIncrement>2</dimIncrement><name>MODE%s</name>
+				<bitOffset>0</bitOffset><bitRange>[1:0]</bitRange></field>`,
+			want: [][]string{
+				{"name=MODE0", "bitOffset=0", "bitRange=[1:0]"},
+				{"name=MODE1", "bitOffset=2", "bitRange=[3:2]"},
+			},
+		},
+		{
+			name:    "name without placeholder",
+			elem:    `<register><dim>2</dim><dimIncrement>4</dimIncrement><name>CR</name></register>`,
+			wantErr: true,
+		},
+		{
+			name:    "dimIndex count differs from dim",
+			elem:    `<register><dim>3</dim><dimIncrement>4</dimIncrement><dimIndex>A,B</dimIndex><name>CR%s</name></register>`,
+			wantErr: true,
+		},
+		{
+			name:    "missing dimIncrement",
+			elem:    `<register><dim>2</dim><name>CR%s</name></register>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, err := expandDimElement(readTestSVD(t, tt.elem))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expandDimElement = %d instances, want an error", len(instances))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][]string
+			for _, instance := range instances {
+				got = append(got, leafChildren(instance))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("instances = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Element is a node of an in-memory SVD document tree.
+type Element struct {
+	Name     string
+	Attrs    map[string]string
+	Text     string
+	Children []*Element
+
+	// dimArray and dimIndex link an expanded dim instance back to its array definition.
+	dimArray string
+	dimIndex string
+}
+
+// ReadSVDTree reads an SVD file into an element tree and returns the root element.
+func ReadSVDTree(filename string, bufferSize int) (*Element, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, bufferSize)
+	decoder := xml.NewDecoder(reader)
+
+	var root *Element
+	var stack []*Element
+	var textBuilder strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("XML parsing error: %w", err)
+		}
+
+		switch elem := token.(type) {
+		case xml.StartElement:
+			node := &Element{Name: elem.Name.Local}
+			for _, attr := range elem.Attr {
+				if attr.Name.Space != "" {
+					continue
+				}
+				if node.Attrs == nil {
+					node.Attrs = make(map[string]string)
+				}
+				node.Attrs[attr.Name.Local] = attr.Value
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+			textBuilder.Reset()
+
+		case xml.CharData:
+			textBuilder.Write(elem)
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			node := stack[len(stack)-1]
+			if len(node.Children) == 0 {
+				node.Text = strings.TrimSpace(textBuilder.String())
+			}
+			stack = stack[:len(stack)-1]
+			textBuilder.Reset()
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("empty XML document")
+	}
+
+	return root, nil
+}
+
+// Attr returns the value of an attribute, or "" if absent.
+func (e *Element) Attr(name string) string {
+	if e.Attrs == nil {
+		return ""
+	}
+	return e.Attrs[name]
+}
+
+// SetAttr sets an attribute value, removing the attribute when value is empty.
+func (e *Element) SetAttr(name, value string) {
+	if value == "" {
+		delete(e.Attrs, name)
+		return
+	}
+	if e.Attrs == nil {
+		e.Attrs = make(map[string]string)
+	}
+	e.Attrs[name] = value
+}
+
+// Child returns the first child element with the given name.
+func (e *Element) Child(name string) *Element {
+	for _, c := range e.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// ChildText returns the text of the first child element with the given name.
+func (e *Element) ChildText(name string) string {
+	if c := e.Child(name); c != nil {
+		return c.Text
+	}
+	return ""
+}
+
+// ChildrenNamed returns all child elements with the given name.
+func (e *Element) ChildrenNamed(name string) []*Element {
+	var result []*Element
+	for _, c := range e.Children {
+		if c.Name == name {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// SetChildText replaces the text of the first child with the given name, appending it if absent.
+func (e *Element) SetChildText(name, text string) {
+	if c := e.Child(name); c != nil {
+		c.Text = text
+		return
+	}
+	e.Children = append(e.Children, &Element{Name: name, Text: text})
+}
+
+// RemoveChildren removes all child elements with the given name.
+func (e *Element) RemoveChildren(name string) {
+	kept := e.Children[:0]
+	for _, c := range e.Children {
+		if c.Name != name {
+			kept = append(kept, c)
+		}
+	}
+	e.Children = kept
+}
+
+// IsLeaf reports whether the element holds text rather than child elements.
+func (e *Element) IsLeaf() bool {
+	return len(e.Children) == 0
+}
+
+// Clone returns a deep copy of the element.
+func (e *Element) Clone() *Element {
+	clone := &Element{Name: e.Name, Text: e.Text, dimArray: e.dimArray, dimIndex: e.dimIndex}
+	if e.Attrs != nil {
+		clone.Attrs = make(map[string]string, len(e.Attrs))
+		for k, v := range e.Attrs {
+			clone.Attrs[k] = v
+		}
+	}
+	if len(e.Children) > 0 {
+		clone.Children = make([]*Element, len(e.Children))
+		for i, c := range e.Children {
+			clone.Children[i] = c.Clone()
+		}
+	}
+	return clone
+}
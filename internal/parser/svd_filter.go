@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// filterPattern is an --include or --exclude glob. Patterns without a dot match a
+// peripheral's name or groupName; "PERIPH.REG" patterns also select registers (or
+// clusters) by their path inside the peripheral, e.g. USART*.CR* or DMAC.CH*.CFG.
+type filterPattern struct {
+	peripheral string
+	register   string
+}
+
+func parseFilterPatterns(patterns []string) []filterPattern {
+	parsed := make([]filterPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		peripheral, register, _ := strings.Cut(strings.TrimSpace(pattern), ".")
+		parsed = append(parsed, filterPattern{
+			peripheral: peripheral,
+			register:   strings.ReplaceAll(register, ".", "/"),
+		})
+	}
+	return parsed
+}
+
+// CheckFilterPatterns reports the first malformed glob pattern.
+func CheckFilterPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(strings.ReplaceAll(pattern, ".", "/"), ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (f filterPattern) matchesPeripheral(peripheral *Element) bool {
+	for _, value := range []string{peripheral.ChildText("name"), peripheral.ChildText("groupName")} {
+		if matched, _ := path.Match(f.peripheral, value); matched && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (f filterPattern) matchesRegister(registerPath string) bool {
+	matched, _ := path.Match(f.register, registerPath)
+	return matched
+}
+
+// peripheralFilter holds the register patterns that apply to one peripheral.
+type peripheralFilter struct {
+	include []filterPattern // nil keeps every register
+	exclude []filterPattern
+}
+
+// filterPeripherals removes the peripherals, clusters and registers not selected by
+// the include and exclude patterns. It runs after derivedFrom resolution, so kept
+// elements derived from a removed base still carry its registers and fields.
+func filterPeripherals(device *Element, include, exclude []string) string {
+	includes := parseFilterPatterns(include)
+	excludes := parseFilterPatterns(exclude)
+
+	peripherals := device.Child("peripherals")
+	if peripherals == nil {
+		return ""
+	}
+
+	total, kept := 0, 0
+	children := peripherals.Children[:0]
+	for _, peripheral := range peripherals.Children {
+		if peripheral.Name != "peripheral" {
+			children = append(children, peripheral)
+			continue
+		}
+		total++
+
+		filter, ok := selectPeripheral(peripheral, includes, excludes)
+		if !ok {
+			continue
+		}
+		if registers := peripheral.Child("registers"); registers != nil {
+			filterRegisters(registers, "", filter)
+		}
+		children = append(children, peripheral)
+		kept++
+	}
+	peripherals.Children = children
+
+	return fmt.Sprintf("Peripheral filter: kept %d of %d peripherals", kept, total)
+}
+
+// selectPeripheral decides whether a peripheral is kept and which of its registers.
+// A peripheral selected only by register patterns keeps just the matching registers.
+func selectPeripheral(peripheral *Element, includes, excludes []filterPattern) (peripheralFilter, bool) {
+	var filter peripheralFilter
+
+	for _, pattern := range excludes {
+		if !pattern.matchesPeripheral(peripheral) {
+			continue
+		}
+		if pattern.register == "" {
+			return filter, false
+		}
+		filter.exclude = append(filter.exclude, pattern)
+	}
+
+	if len(includes) == 0 {
+		return filter, true
+	}
+	whole := false
+	for _, pattern := range includes {
+		if !pattern.matchesPeripheral(peripheral) {
+			continue
+		}
+		if pattern.register == "" {
+			whole = true
+			continue
+		}
+		filter.include = append(filter.include, pattern)
+	}
+	if whole {
+		filter.include = nil
+		return filter, true
+	}
+	return filter, len(filter.include) > 0
+}
+
+// filterRegisters removes the registers and clusters of a registers or cluster element
+// that the filter drops. A matching cluster path selects the whole cluster; clusters
+// left without registers are removed.
+func filterRegisters(container *Element, prefix string, filter peripheralFilter) {
+	children := container.Children[:0]
+	for _, c := range container.Children {
+		if c.Name != "register" && c.Name != "cluster" {
+			children = append(children, c)
+			continue
+		}
+
+		itemPath := prefix + c.ChildText("name")
+		if matchesAny(filter.exclude, itemPath) {
+			continue
+		}
+
+		if c.Name == "cluster" {
+			if filter.include == nil || matchesAny(filter.include, itemPath) {
+				filterRegisters(c, itemPath+"/", peripheralFilter{exclude: filter.exclude})
+			} else {
+				filterRegisters(c, itemPath+"/", filter)
+			}
+			if len(c.ChildrenNamed("register")) == 0 && len(c.ChildrenNamed("cluster")) == 0 {
+				continue
+			}
+		} else if filter.include != nil && !matchesAny(filter.include, itemPath) {
+			continue
+		}
+		children = append(children, c)
+	}
+	container.Children = children
+}
+
+func matchesAny(patterns []filterPattern, registerPath string) bool {
+	for _, pattern := range patterns {
+		if pattern.matchesRegister(registerPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const filterTestSVD = `<device>
+  <peripherals>
+    <peripheral>
+      <name>USART1</name>
+      <groupName>USART</groupName>
+      <registers>
+        <register><name>SR</name></register>
+        <register><name>CR1</name></register>
+        <register><name>CR2</name></register>
+      </registers>
+    </peripheral>
+    <peripheral>
+      <name>UART4</name>
+      <groupName>USART</groupName>
+      <registers>
+        <register><name>SR</name></register>
+        <register><name>CR1</name></register>
+      </registers>
+    </peripheral>
+    <peripheral>
+      <name>DMAC</name>
+      <registers>
+        <register><name>CTRL</name></register>
+        <cluster>
+          <name>CH0</name>
+          <register><name>CFG</name></register>
+          <register><name>PAR</name></register>
+        </cluster>
+        <cluster>
+          <name>CH1</name>
+          <register><name>CFG</name></register>
+          <register><name>PAR</name></register>
+        </cluster>
+      </registers>
+    </peripheral>
+    <peripheral><name>GPIOA</name></peripheral>
+  </peripherals>
+</device>`
+
+// registerPaths lists every kept peripheral with its register paths, e.g. "DMAC:CTRL,CH0.CFG".
+func registerPaths(device *Element) []string {
+	var paths []string
+	for _, peripheral := range device.Child("peripherals").ChildrenNamed("peripheral") {
+		var registers []string
+		if container := peripheral.Child("registers"); container != nil {
+			registers = containerPaths(container, "")
+		}
+		paths = append(paths, peripheral.ChildText("name")+":"+strings.Join(registers, ","))
+	}
+	return paths
+}
+
+func containerPaths(container *Element, prefix string) []string {
+	var paths []string
+	for _, item := range registerItems(container) {
+		name := prefix + item.ChildText("name")
+		if item.Name == "cluster" {
+			paths = append(paths, containerPaths(item, name+".")...)
+			continue
+		}
+		paths = append(paths, name)
+	}
+	return paths
+}
+
+func TestFilterPeripherals(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name: "no patterns keep everything",
+			want: []string{"USART1:SR,CR1,CR2", "UART4:SR,CR1", "DMAC:CTRL,CH0.CFG,CH0.PAR,CH1.CFG,CH1.PAR", "GPIOA:"},
+		},
+		{
+			name:    "peripheral glob",
+			include: []string{"UART*"},
+			want:    []string{"UART4:SR,CR1"},
+		},
+		{
+			name:    "groupName glob",
+			include: []string{"USART"},
+			want:    []string{"USART1:SR,CR1,CR2", "UART4:SR,CR1"},
+		},
+		{
+			name:    "glob matches names and groupNames",
+			include: []string{"USART*"},
+			want:    []string{"USART1:SR,CR1,CR2", "UART4:SR,CR1"},
+		},
+		{
+			name:    "several patterns",
+			include: []string{"GPIO?", "UART4"},
+			want:    []string{"UART4:SR,CR1", "GPIOA:"},
+		},
+		{
+			name:    "exclude peripheral",
+			exclude: []string{"DMAC", "GPIO*"},
+			want:    []string{"USART1:SR,CR1,CR2", "UART4:SR,CR1"},
+		},
+		{
+			name:    "register pattern keeps only matching registers",
+			include: []string{"USART1.CR*"},
+			want:    []string{"USART1:CR1,CR2"},
+		},
+		{
+			name:    "register pattern through groupName",
+			include: []string{"USART.CR*"},
+			want:    []string{"USART1:CR1,CR2", "UART4:CR1"},
+		},
+		{
+			name:    "whole peripheral wins over register pattern",
+			include: []string{"USART1.SR", "USART1"},
+			want:    []string{"USART1:SR,CR1,CR2"},
+		},
+		{
+			name:    "exclude register",
+			exclude: []string{"*.SR"},
+			want:    []string{"USART1:CR1,CR2", "UART4:CR1", "DMAC:CTRL,CH0.CFG,CH0.PAR,CH1.CFG,CH1.PAR", "GPIOA:"},
+		},
+		{
+			name:    "dotted path into clusters",
+			include: []string{"DMAC.CH*.CFG"},
+			want:    []string{"DMAC:CH0.CFG,CH1.CFG"},
+		},
+		{
+			name:    "cluster path selects the whole cluster",
+			include: []string{"DMAC.CH1"},
+			want:    []string{"DMAC:CH1.CFG,CH1.PAR"},
+		},
+		{
+			name:    "emptied clusters are removed",
+			include: []string{"DMAC"},
+			exclude: []string{"DMAC.CH0.*"},
+			want:    []string{"DMAC:CTRL,CH1.CFG,CH1.PAR"},
+		},
+		{
+			name:    "exclusion inside an included cluster",
+			include: []string{"DMAC.CH0"},
+			exclude: []string{"DMAC.CH0.PAR"},
+			want:    []string{"DMAC:CH0.CFG"},
+		},
+		{
+			name:    "register glob does not reach into clusters",
+			include: []string{"DMAC.*FG"},
+			want:    []string{"DMAC:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := readTestSVD(t, filterTestSVD)
+			filterPeripherals(device, tt.include, tt.exclude)
+			if got := registerPaths(device); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckFilterPatterns(t *testing.T) {
+	if err := CheckFilterPatterns([]string{"USART*", "DMA?.CH[0-3].CFG"}); err != nil {
+		t.Errorf("valid patterns: %v", err)
+	}
+	if err := CheckFilterPatterns([]string{"GPIO[A"}); err == nil {
+		t.Error("unterminated class: want an error")
+	}
+}
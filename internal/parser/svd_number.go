@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// scaleSuffixes are the multipliers of the scaledNonNegativeInteger k, M, G and T suffixes.
+var scaleSuffixes = map[byte]uint64{
+	'k': 1 << 10, 'K': 1 << 10,
+	'm': 1 << 20, 'M': 1 << 20,
+	'g': 1 << 30, 'G': 1 << 30,
+	't': 1 << 40, 'T': 1 << 40,
+}
+
+// ParseSVDInt parses an SVD scaledNonNegativeInteger: an optional +, then hex (0x),
+// binary (# or 0b) or decimal digits, then an optional k, M, G or T multiplier
+// (powers of 1024). Binary values with x don't-care bits are rejected; use
+// ParseSVDPattern for those.
+func ParseSVDInt(s string) (uint64, error) {
+	value, mask, err := ParseSVDPattern(s)
+	if err != nil {
+		return 0, err
+	}
+	if mask != ^uint64(0) {
+		return 0, fmt.Errorf("%q has don't-care bits", strings.TrimSpace(s))
+	}
+	return value, nil
+}
+
+// ParseSVDPattern parses a scaledNonNegativeInteger that may contain x don't-care
+// digits in binary form (e.g. "#1x0"). It returns the value with the don't-care bits
+// cleared and a mask of the bits that must match.
+func ParseSVDPattern(s string) (value, mask uint64, err error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "+")
+	if s == "" {
+		return 0, 0, fmt.Errorf("empty number")
+	}
+
+	scale := uint64(1)
+	if multiplier, ok := scaleSuffixes[s[len(s)-1]]; ok {
+		scale = multiplier
+		s = s[:len(s)-1]
+	}
+
+	mask = ^uint64(0)
+	switch {
+	case isHexLiteral(s):
+		value, err = strconv.ParseUint(s[2:], 16, 64)
+	case strings.HasPrefix(s, "#"), strings.HasPrefix(s, "0b"), strings.HasPrefix(s, "0B"):
+		digits := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(s, "#"), "0b"), "0B")
+		value, mask, err = parseBinaryPattern(digits)
+	default:
+		value, err = strconv.ParseUint(s, 10, 64)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if scale > 1 {
+		if mask != ^uint64(0) {
+			return 0, 0, fmt.Errorf("%q: a multiplier cannot scale don't-care bits", s)
+		}
+		if value > ^uint64(0)/scale {
+			return 0, 0, fmt.Errorf("%q: value out of range", s)
+		}
+		value *= scale
+	}
+	return value, mask, nil
+}
+
+// isHexLiteral reports whether s starts with 0x. The k, M, G and T suffixes are not
+// hex digits, so they are stripped before this check.
+func isHexLiteral(s string) bool {
+	return len(s) > 2 && (strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"))
+}
+
+// parseBinaryPattern parses binary digits where x or X marks a don't-care bit.
+func parseBinaryPattern(digits string) (value, mask uint64, err error) {
+	if digits == "" || len(digits) > 64 {
+		return 0, 0, fmt.Errorf("invalid binary number %q", digits)
+	}
+	mask = ^uint64(0)
+	for i := 0; i < len(digits); i++ {
+		bit := uint64(1) << uint(len(digits)-1-i)
+		switch digits[i] {
+		case '1':
+			value |= bit
+		case '0':
+		case 'x', 'X':
+			mask &^= bit
+		default:
+			return 0, 0, fmt.Errorf("invalid binary number %q", digits)
+		}
+	}
+	return value, mask, nil
+}
+
+// formatHex formats a computed value in the 0x-prefixed style used throughout SVD files.
+func formatHex(value uint64) string {
+	return fmt.Sprintf("0x%X", value)
+}
+
+// FormatHexWidth formats a value as hex zero-padded to the given width in bits, the
+// way addresses and masks appear in the computed columns.
+func FormatHexWidth(value uint64, bits uint64) string {
+	return fmt.Sprintf("0x%0*X", int((bits+3)/4), value)
+}
+
+// matchEnumValue reports whether value matches an enumeratedValue value, which may be
+// a binary pattern such as "#1x0" whose x digits match either bit.
+func matchEnumValue(pattern string, value uint64) bool {
+	expected, mask, err := ParseSVDPattern(pattern)
+	return err == nil && value&mask == expected
+}
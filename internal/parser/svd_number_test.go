@@ -0,0 +1,139 @@
+package parser
+
+import "testing"
+
+func TestParseSVDPattern(t *testing.T) {
+	const all = ^uint64(0)
+	tests := []struct {
+		in      string
+		value   uint64
+		mask    uint64
+		wantErr bool
+	}{
+		{in: "0", value: 0, mask: all},
+		{in: "42", value: 42, mask: all},
+		{in: "+42", value: 42, mask: all},
+		{in: " 0x40020000 ", value: 0x40020000, mask: all},
+		{in: "0X20", value: 0x20, mask: all},
+		{in: "0xFFFFFFFFFFFFFFFF", value: all, mask: all},
+		{in: "#0101", value: 5, mask: all},
+		{in: "0b11", value: 3, mask: all},
+		{in: "0B10", value: 2, mask: all},
+		{in: "#1x0", value: 4, mask: all &^ 2},
+		{in: "0bXX1", value: 1, mask: all &^ 6},
+		{in: "4k", value: 4 << 10, mask: all},
+		{in: "4K", value: 4 << 10, mask: all},
+		{in: "2M", value: 2 << 20, mask: all},
+		{in: "1g", value: 1 << 30, mask: all},
+		{in: "3T", value: 3 << 40, mask: all},
+		{in: "0x10k", value: 0x10 << 10, mask: all},
+		{in: "#10K", value: 2 << 10, mask: all},
+		{in: "16777215T", value: 16777215 << 40, mask: all},
+		{in: "16777216T", wantErr: true},
+		{in: "18446744073709551616", wantErr: true},
+		{in: "#1xk", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "+", wantErr: true},
+		{in: "k", wantErr: true},
+		{in: "0x", wantErr: true},
+		{in: "0xG", wantErr: true},
+		{in: "#", wantErr: true},
+		{in: "#102", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "1_000", wantErr: true},
+		{in: "0x1_0", wantErr: true},
+		{in: "12kk", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			value, mask, err := ParseSVDPattern(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSVDPattern(%q) = %#x/%#x, want an error", tt.in, value, mask)
+				}
+				return
+			}
+			if err != nil || value != tt.value || mask != tt.mask {
+				t.Errorf("ParseSVDPattern(%q) = %#x/%#x, %v, want %#x/%#x", tt.in, value, mask, err, tt.value, tt.mask)
+			}
+		})
+	}
+}
+
+func TestParseSVDIntRejectsDontCareBits(t *testing.T) {
+	if value, err := ParseSVDInt("#1x0"); err == nil {
+		t.Errorf("ParseSVDInt(#1x0) = %d, want an error", value)
+	}
+	if value, err := ParseSVDInt("#110"); err != nil || value != 6 {
+		t.Errorf("ParseSVDInt(#110) = %d, %v, want 6", value, err)
+	}
+}
+
+func TestMatchEnumValue(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   uint64
+		want    bool
+	}{
+		{"5", 5, true},
+		{"0x5", 4, false},
+		{"#1x0", 4, true},
+		{"#1x0", 6, true},
+		{"#1x0", 5, false},
+		{"0bxx", 3, true},
+		{"bad", 0, false},
+	}
+	for _, tt := range tests {
+		if got := matchEnumValue(tt.pattern, tt.value); got != tt.want {
+			t.Errorf("matchEnumValue(%q, %d) = %v, want %v", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaNumberFormatMatchesParser(t *testing.T) {
+	tests := []struct {
+		parent, leaf string
+		values       []string
+		parse        func(string) error
+	}{
+		{
+			parent: "register",
+			leaf:   "addressOffset",
+			values: []string{"0x10", "#0101", "0b11", "4k", "+8", "0x", "#1x0", "12kk", "16777216T"},
+			parse: func(value string) error {
+				_, err := ParseSVDInt(value)
+				return err
+			},
+		},
+		{
+			parent: "field",
+			leaf:   "bitRange",
+			values: []string{"[7:4]", "[0x7:4]", "[ 7 : 4 ]", "[4:7]", "[7-4]", "[7:]", "[x:4]", "[7:4:0]"},
+			parse: func(value string) error {
+				_, _, err := parseBitRange(value)
+				return err
+			},
+		},
+		{
+			parent: "interrupt",
+			leaf:   "value",
+			values: []string{"16", "0x10", "-1", "#10", "1.5", "IRQ"},
+			parse: func(value string) error {
+				_, err := ParseInterruptValue(value)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, value := range tt.values {
+			t.Run(tt.leaf+"/"+value, func(t *testing.T) {
+				parseErr := tt.parse(value)
+				checker := &schemaChecker{}
+				checker.checkValue(&Element{Name: tt.parent}, &Element{Name: tt.leaf, Text: value}, "X."+tt.leaf)
+				if reported := len(checker.issues) > 0; reported != (parseErr != nil) {
+					t.Errorf("validator reported %v, parser error %v", checker.issues, parseErr)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,643 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// svdPatcher applies svdtools-style YAML patch files to an SVD element tree.
+//
+// A patch is a mapping of commands and element globs at each level of the device:
+//
+//	_include: [common.yaml]           # other patch files, relative to this one
+//	_delete: [ETH*]                   # peripherals (registers, fields) to remove
+//	_modify:                          # properties to set; name renames, ~ removes
+//	  version: "1.1"
+//	  GPIOA: {description: Port A}
+//	_add:                             # new elements, with nested registers/fields
+//	  CRC2: {baseAddress: 0x40023400, registers: {DR: {addressOffset: 0}}}
+//	_derive:                          # derivedFrom, creating the element if needed
+//	  GPIOK: {_from: GPIOA, baseAddress: 0x40022800}
+//	USART*:                           # commands for matching peripherals
+//	  CR1:                            # commands for matching registers
+//	    _modify: {UE: {access: read-write}}
+//	    PS: {Even: [0, Even parity], Odd: [1, Odd parity]}  # field enum values
+//	    DIV: [0, 4095]                # field writeConstraint range
+//
+// Globs follow path.Match and may list alternatives separated by commas.
+type svdPatcher struct {
+	warnings  []string
+	applied   map[string]bool // files already applied, skipped when included again
+	including map[string]bool // files on the current _include chain
+}
+
+// applyPatchFile applies a patch file and its includes to the device, returning
+// warnings for globs that matched nothing and commands that are not supported.
+func applyPatchFile(device *Element, filename string) ([]string, error) {
+	p := &svdPatcher{applied: make(map[string]bool), including: make(map[string]bool)}
+	if err := p.applyFile(device, filename); err != nil {
+		return p.warnings, err
+	}
+	return p.warnings, nil
+}
+
+func (p *svdPatcher) warn(format string, args ...interface{}) {
+	p.warnings = append(p.warnings, "patch: "+fmt.Sprintf(format, args...))
+}
+
+// applyFile applies a patch file after its includes. A file reached again through
+// another include is skipped; one that includes itself is an error.
+func (p *svdPatcher) applyFile(device *Element, filename string) error {
+	absolute, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	if p.including[absolute] {
+		return fmt.Errorf("%s includes itself", filename)
+	}
+	if p.applied[absolute] {
+		return nil
+	}
+	p.including[absolute] = true
+	defer delete(p.including, absolute)
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: a patch must be a mapping", filename)
+	}
+
+	if includes := mappingValue(root, "_include"); includes != nil {
+		for _, include := range sequenceValues(includes) {
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(filename), include)
+			}
+			if err := p.applyFile(device, include); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := p.applyDevice(device, root); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	p.applied[absolute] = true
+	return nil
+}
+
+// applyDevice runs the device level commands, then the commands of each peripheral glob.
+func (p *svdPatcher) applyDevice(device *Element, node *yaml.Node) error {
+	peripherals := ensureChild(device, "peripherals")
+
+	for _, pair := range mappingPairs(node) {
+		key, value := pair[0].Value, pair[1]
+		switch key {
+		case "_include", "_svd":
+		case "_delete":
+			for _, glob := range sequenceValues(value) {
+				if removeMatching(peripherals, "peripheral", glob) == 0 {
+					p.warn("_delete: no peripheral matches %q", glob)
+				}
+			}
+		case "_modify":
+			if err := p.modifyDevice(device, peripherals, value); err != nil {
+				return err
+			}
+		case "_add":
+			if err := p.addElements(peripherals, "peripheral", value); err != nil {
+				return err
+			}
+		case "_derive":
+			if err := p.deriveElements(peripherals, "peripheral", value); err != nil {
+				return err
+			}
+		default:
+			if strings.HasPrefix(key, "_") {
+				p.warn("unsupported device command %s (line %d)", key, pair[0].Line)
+				continue
+			}
+			if value.Kind != yaml.MappingNode {
+				return fmt.Errorf("line %d: peripheral %s: expected a mapping of commands", value.Line, key)
+			}
+			matched := matchingChildren(peripherals, "peripheral", key)
+			if len(matched) == 0 {
+				p.warn("no peripheral matches %q", key)
+			}
+			for _, peripheral := range matched {
+				if err := p.applyPeripheral(peripheral, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// modifyDevice sets device properties (scalars and the cpu mapping) and the
+// properties of peripherals named by glob keys.
+func (p *svdPatcher) modifyDevice(device, peripherals *Element, node *yaml.Node) error {
+	for _, pair := range mappingPairs(node) {
+		key, value := pair[0].Value, pair[1]
+		if value.Kind != yaml.MappingNode || key == "cpu" {
+			if err := p.setProperty(device, key, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		matched := matchingChildren(peripherals, "peripheral", key)
+		if len(matched) == 0 {
+			p.warn("_modify: no peripheral matches %q", key)
+		}
+		for _, peripheral := range matched {
+			if err := p.setProperties(peripheral, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyPeripheral runs the peripheral level commands, then the commands of each register glob.
+func (p *svdPatcher) applyPeripheral(peripheral *Element, node *yaml.Node) error {
+	name := peripheral.ChildText("name")
+	registers := peripheral.Child("registers")
+	if base := peripheral.Attr("derivedFrom"); base != "" && registers == nil {
+		p.warn("%s derives its registers from %s; patch %s or _derive a copy instead", name, base, base)
+	}
+
+	for _, pair := range mappingPairs(node) {
+		key, value := pair[0].Value, pair[1]
+		switch key {
+		case "_delete":
+			p.deleteRegisters(peripheral, registers, value)
+		case "_modify":
+			for _, modify := range mappingPairs(value) {
+				if modify[0].Value == "_interrupts" {
+					if err := p.modifyChildren(name, peripheral, "interrupt", modify[1]); err != nil {
+						return err
+					}
+					continue
+				}
+				matched := append(matchingChildren(registers, "register", modify[0].Value), matchingChildren(registers, "cluster", modify[0].Value)...)
+				if len(matched) == 0 {
+					p.warn("%s _modify: no register matches %q", name, modify[0].Value)
+				}
+				for _, register := range matched {
+					if err := p.setProperties(register, modify[1]); err != nil {
+						return err
+					}
+				}
+			}
+		case "_add":
+			for _, add := range mappingPairs(value) {
+				var err error
+				if add[0].Value == "_interrupts" {
+					err = p.addElements(peripheral, "interrupt", add[1])
+				} else {
+					registers = ensureChild(peripheral, "registers")
+					err = p.addElement(registers, "register", add[0].Value, add[1])
+				}
+				if err != nil {
+					return err
+				}
+			}
+		case "_derive":
+			registers = ensureChild(peripheral, "registers")
+			if err := p.deriveElements(registers, "register", value); err != nil {
+				return err
+			}
+		default:
+			if strings.HasPrefix(key, "_") {
+				p.warn("%s: unsupported peripheral command %s (line %d)", name, key, pair[0].Line)
+				continue
+			}
+			if value.Kind != yaml.MappingNode {
+				return fmt.Errorf("line %d: register %s.%s: expected a mapping of commands", value.Line, name, key)
+			}
+			matched := matchingChildren(registers, "register", key)
+			if len(matched) == 0 {
+				p.warn("%s: no register matches %q", name, key)
+			}
+			for _, register := range matched {
+				if err := p.applyRegister(register, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// deleteRegisters removes registers and clusters by glob. The mapping form also
+// deletes interrupts: {_registers: [...], _interrupts: [...]}.
+func (p *svdPatcher) deleteRegisters(peripheral, registers *Element, node *yaml.Node) {
+	name := peripheral.ChildText("name")
+	if node.Kind == yaml.MappingNode {
+		for _, glob := range sequenceValues(mappingValue(node, "_interrupts")) {
+			if removeMatching(peripheral, "interrupt", glob) == 0 {
+				p.warn("%s _delete: no interrupt matches %q", name, glob)
+			}
+		}
+		node = mappingValue(node, "_registers")
+	}
+	for _, glob := range sequenceValues(node) {
+		if removeMatching(registers, "register", glob)+removeMatching(registers, "cluster", glob) == 0 {
+			p.warn("%s _delete: no register matches %q", name, glob)
+		}
+	}
+}
+
+// applyRegister runs the register level commands, then the enum and range
+// additions of each field glob.
+func (p *svdPatcher) applyRegister(register *Element, node *yaml.Node) error {
+	name := register.ChildText("name")
+	fields := register.Child("fields")
+
+	for _, pair := range mappingPairs(node) {
+		key, value := pair[0].Value, pair[1]
+		switch key {
+		case "_delete":
+			for _, glob := range sequenceValues(value) {
+				if removeMatching(fields, "field", glob) == 0 {
+					p.warn("%s _delete: no field matches %q", name, glob)
+				}
+			}
+		case "_modify":
+			if err := p.modifyChildren(name, fields, "field", value); err != nil {
+				return err
+			}
+		case "_add":
+			fields = ensureChild(register, "fields")
+			if err := p.addElements(fields, "field", value); err != nil {
+				return err
+			}
+		case "_derive":
+			fields = ensureChild(register, "fields")
+			if err := p.deriveElements(fields, "field", value); err != nil {
+				return err
+			}
+		default:
+			if strings.HasPrefix(key, "_") {
+				p.warn("%s: unsupported register command %s (line %d)", name, key, pair[0].Line)
+				continue
+			}
+			matched := matchingChildren(fields, "field", key)
+			if len(matched) == 0 {
+				p.warn("%s: no field matches %q", name, key)
+			}
+			for _, field := range matched {
+				if err := p.applyField(field, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// applyField adds enumerated values ({NAME: [value, description]}, optionally under
+// _read, _write or _replace_enum) or a writeConstraint range ([min, max]) to a field.
+func (p *svdPatcher) applyField(field *Element, node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		bounds := sequenceValues(node)
+		if len(bounds) != 2 {
+			return fmt.Errorf("line %d: field %s: a range needs [minimum, maximum]", node.Line, field.ChildText("name"))
+		}
+		field.RemoveChildren("writeConstraint")
+		field.Children = append(field.Children, WriteConstraintElement(fmt.Sprintf("range %s-%s", bounds[0], bounds[1])))
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: field %s: expected enumerated values or a range", node.Line, field.ChildText("name"))
+	}
+
+	replace := false
+	for _, pair := range mappingPairs(node) {
+		switch pair[0].Value {
+		case "_read":
+			if err := p.addEnumeratedValues(field, "read", pair[1], false); err != nil {
+				return err
+			}
+		case "_write":
+			if err := p.addEnumeratedValues(field, "write", pair[1], false); err != nil {
+				return err
+			}
+		case "_replace_enum":
+			replace = true
+			if err := p.addEnumeratedValues(field, "", pair[1], true); err != nil {
+				return err
+			}
+		}
+	}
+	if replace || mappingValue(node, "_read") != nil || mappingValue(node, "_write") != nil {
+		return nil
+	}
+	return p.addEnumeratedValues(field, "", node, false)
+}
+
+// addEnumeratedValues adds an enumeratedValues set with the given usage. An existing
+// set of the same usage is kept unless replace is set.
+func (p *svdPatcher) addEnumeratedValues(field *Element, usage string, node *yaml.Node, replace bool) error {
+	name := field.ChildText("name")
+	kept := field.Children[:0]
+	for _, c := range field.Children {
+		if c.Name == "enumeratedValues" && sameUsage(c.ChildText("usage"), usage) {
+			if !replace {
+				p.warn("%s already has enumeratedValues, use _replace_enum to replace them", name)
+				return nil
+			}
+			continue
+		}
+		kept = append(kept, c)
+	}
+	field.Children = kept
+
+	set := &Element{Name: "enumeratedValues"}
+	if usage != "" {
+		set.SetChildText("usage", usage)
+	}
+	for _, pair := range mappingPairs(node) {
+		if strings.HasPrefix(pair[0].Value, "_") {
+			continue
+		}
+		value := &Element{Name: "enumeratedValue"}
+		value.SetChildText("name", pair[0].Value)
+		switch pair[1].Kind {
+		case yaml.SequenceNode:
+			entry := sequenceValues(pair[1])
+			if len(entry) == 0 {
+				return fmt.Errorf("line %d: %s.%s: missing value", pair[1].Line, name, pair[0].Value)
+			}
+			if len(entry) > 1 {
+				value.SetChildText("description", entry[1])
+			}
+			value.SetChildText("value", entry[0])
+		case yaml.ScalarNode:
+			value.SetChildText("value", pair[1].Value)
+		default:
+			return fmt.Errorf("line %d: %s.%s: expected [value, description]", pair[1].Line, name, pair[0].Value)
+		}
+		set.Children = append(set.Children, value)
+	}
+	field.Children = append(field.Children, set)
+	return nil
+}
+
+// sameUsage reports whether two enumeratedValues usages overlap; read-write (the
+// default) overlaps everything.
+func sameUsage(a, b string) bool {
+	if a == "" || a == "read-write" || b == "" || b == "read-write" {
+		return true
+	}
+	return a == b
+}
+
+// modifyChildren sets the properties of the children with the given tag named by glob
+// keys. The parent may be nil, e.g. the fields of a register without any; owner names
+// the element being patched in warnings.
+func (p *svdPatcher) modifyChildren(owner string, parent *Element, tag string, node *yaml.Node) error {
+	for _, pair := range mappingPairs(node) {
+		matched := matchingChildren(parent, tag, pair[0].Value)
+		if len(matched) == 0 {
+			p.warn("%s _modify: no %s matches %q", owner, tag, pair[0].Value)
+		}
+		for _, child := range matched {
+			if err := p.setProperties(child, pair[1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addElements appends a new element with the given tag for every NAME: {properties} entry.
+func (p *svdPatcher) addElements(parent *Element, tag string, node *yaml.Node) error {
+	for _, pair := range mappingPairs(node) {
+		if err := p.addElement(parent, tag, pair[0].Value, pair[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *svdPatcher) addElement(parent *Element, tag, name string, spec *yaml.Node) error {
+	if len(matchingChildren(parent, tag, name)) > 0 {
+		p.warn("_add: %s %q already exists", tag, name)
+		return nil
+	}
+	elem := &Element{Name: tag}
+	elem.SetChildText("name", name)
+	if err := p.setProperties(elem, spec); err != nil {
+		return err
+	}
+	parent.Children = append(parent.Children, elem)
+	return nil
+}
+
+// deriveElements makes NAME derive from a sibling, given as a plain name or as
+// {_from: BASE, ...properties}. Existing elements drop their own registers or fields;
+// missing ones are created.
+func (p *svdPatcher) deriveElements(parent *Element, tag string, node *yaml.Node) error {
+	for _, pair := range mappingPairs(node) {
+		name, spec := pair[0].Value, pair[1]
+		base := spec.Value
+		if spec.Kind == yaml.MappingNode {
+			if from := mappingValue(spec, "_from"); from != nil {
+				base = from.Value
+			}
+		}
+		if base == "" {
+			return fmt.Errorf("line %d: _derive %s: missing base element", spec.Line, name)
+		}
+		if len(matchingChildren(parent, tag, base)) == 0 {
+			p.warn("_derive %s: %s %q not found", name, tag, base)
+		}
+
+		matched := matchingChildren(parent, tag, name)
+		if len(matched) == 0 {
+			elem := &Element{Name: tag}
+			elem.SetChildText("name", name)
+			parent.Children = append(parent.Children, elem)
+			matched = []*Element{elem}
+		}
+		for _, elem := range matched {
+			for _, inherited := range []string{"registers", "fields", "enumeratedValues"} {
+				elem.RemoveChildren(inherited)
+			}
+			elem.SetAttr("derivedFrom", base)
+			if spec.Kind == yaml.MappingNode {
+				if err := p.setProperties(elem, spec); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setProperties applies each key of a mapping to an element with setProperty.
+func (p *svdPatcher) setProperties(elem *Element, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: %s %q: expected a mapping of properties", node.Line, elem.Name, elem.ChildText("name"))
+	}
+	for _, pair := range mappingPairs(node) {
+		if pair[0].Value == "_from" {
+			continue
+		}
+		if err := p.setProperty(elem, pair[0].Value, pair[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setProperty sets one child element. A null value removes it, derivedFrom sets the
+// attribute, registers, fields and interrupts hold NAME: {properties} mappings of new
+// elements, and other mappings edit the nested element (e.g. cpu or addressBlock).
+func (p *svdPatcher) setProperty(elem *Element, key string, value *yaml.Node) error {
+	switch {
+	case value.Tag == "!!null":
+		elem.RemoveChildren(key)
+		return nil
+	case key == "derivedFrom":
+		elem.SetAttr("derivedFrom", value.Value)
+		return nil
+	case value.Kind == yaml.ScalarNode:
+		elem.SetChildText(key, value.Value)
+		return nil
+	}
+
+	containers := map[string]string{"registers": "register", "fields": "field", "enumeratedValues": "enumeratedValue"}
+	if tag, ok := containers[key]; ok && value.Kind == yaml.MappingNode {
+		return p.addElements(ensureChild(elem, key), tag, value)
+	}
+	if key == "interrupts" || key == "_interrupts" {
+		return p.addElements(elem, "interrupt", value)
+	}
+
+	switch value.Kind {
+	case yaml.MappingNode:
+		return p.setProperties(ensureChild(elem, key), value)
+	case yaml.SequenceNode:
+		// a list of mappings replaces repeated elements such as addressBlock
+		elem.RemoveChildren(key)
+		for _, item := range value.Content {
+			child := &Element{Name: key}
+			if err := p.setProperties(child, item); err != nil {
+				return err
+			}
+			elem.Children = append(elem.Children, child)
+		}
+		return nil
+	}
+	return fmt.Errorf("line %d: unsupported value for %s", value.Line, key)
+}
+
+// ensureChild returns the first child with the given name, appending an empty one if absent.
+func ensureChild(parent *Element, name string) *Element {
+	if c := parent.Child(name); c != nil {
+		return c
+	}
+	c := &Element{Name: name}
+	parent.Children = append(parent.Children, c)
+	return c
+}
+
+// matchingChildren returns the children with the given tag whose name matches the
+// glob, which may list comma-separated alternatives.
+func matchingChildren(parent *Element, tag, glob string) []*Element {
+	var matched []*Element
+	if parent == nil {
+		return nil
+	}
+	for _, c := range parent.Children {
+		if c.Name == tag && matchGlob(glob, c.ChildText("name")) {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// removeMatching removes the children with the given tag whose name matches the glob
+// and returns how many were removed.
+func removeMatching(parent *Element, tag, glob string) int {
+	if parent == nil {
+		return 0
+	}
+	removed := 0
+	kept := parent.Children[:0]
+	for _, c := range parent.Children {
+		if c.Name == tag && matchGlob(glob, c.ChildText("name")) {
+			removed++
+			continue
+		}
+		kept = append(kept, c)
+	}
+	parent.Children = kept
+	return removed
+}
+
+func matchGlob(glob, name string) bool {
+	for _, alternative := range strings.Split(glob, ",") {
+		if matched, _ := path.Match(strings.TrimSpace(alternative), name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// mappingPairs returns the key and value nodes of a mapping node.
+func mappingPairs(node *yaml.Node) [][2]*yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	return pairs
+}
+
+// mappingValue returns the value node of a key, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for _, pair := range mappingPairs(node) {
+		if pair[0].Value == key {
+			return pair[1]
+		}
+	}
+	return nil
+}
+
+// sequenceValues returns the scalars of a sequence node, or the node itself when it
+// is a single scalar.
+func sequenceValues(node *yaml.Node) []string {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		return []string{node.Value}
+	}
+	var values []string
+	for _, item := range node.Content {
+		if item.Kind == yaml.ScalarNode {
+			values = append(values, item.Value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const patchTestSVD = `<device>
+  <name>TEST</name>
+  <peripherals>
+    <peripheral>
+      <name>RNG</name>
+      <baseAddress>0x50060800</baseAddress>
+      <registers>
+        <register>
+          <name>CR</name>
+          <addressOffset>0x0</addressOffset>
+          <fields>
+            <field><name>EN</name><bitOffset>2</bitOffset><bitWidth>1</bitWidth></field>
+          </fields>
+        </register>
+      </registers>
+    </peripheral>
+  </peripherals>
+</device>`
+
+// writeTestFile writes content to name in dir and returns its path.
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	filename := filepath.Join(dir, name)
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// readTestSVD parses an SVD document held in a string.
+func readTestSVD(t *testing.T, svd string) *Element {
+	t.Helper()
+	device, err := ReadSVDTree(writeTestFile(t, t.TempDir(), "test.svd", svd), 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return device
+}
+
+// findRegister returns the named register of the named peripheral.
+func findRegister(device *Element, peripheral, register string) *Element {
+	for _, p := range device.Child("peripherals").ChildrenNamed("peripheral") {
+		if p.ChildText("name") != peripheral || p.Child("registers") == nil {
+			continue
+		}
+		for _, r := range p.Child("registers").ChildrenNamed("register") {
+			if r.ChildText("name") == register {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+func TestApplyPatchFieldCommandsWithoutFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		warning string
+	}{
+		{"modify", "_modify: {X: {description: x}}", `patch: NEWREG _modify: no field matches "X"`},
+		{"delete", "_delete: [X]", `patch: NEWREG _delete: no field matches "X"`},
+		{"field glob", "X: {_add: {A: 1}}", `patch: NEWREG: no field matches "X"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := readTestSVD(t, patchTestSVD)
+			patch := writeTestFile(t, t.TempDir(), "patch.yaml", `RNG:
+  _add:
+    NEWREG: {addressOffset: "0x8", size: 32}
+  NEWREG:
+    `+tt.command+"\n")
+
+			warnings, err := applyPatchFile(device, patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(warnings) != 1 || warnings[0] != tt.warning {
+				t.Errorf("warnings = %q, want [%q]", warnings, tt.warning)
+			}
+			if findRegister(device, "RNG", "NEWREG") == nil {
+				t.Error("NEWREG was not added")
+			}
+		})
+	}
+}
+
+func TestApplyPatchModifyFieldWarningNamesRegister(t *testing.T) {
+	device := readTestSVD(t, patchTestSVD)
+	patch := writeTestFile(t, t.TempDir(), "patch.yaml", `RNG:
+  CR:
+    _modify:
+      NOPE: {bitWidth: 2}
+      EN: {description: Enable}
+`)
+
+	warnings, err := applyPatchFile(device, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `patch: CR _modify: no field matches "NOPE"`
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("warnings = %q, want [%q]", warnings, want)
+	}
+	field := findRegister(device, "RNG", "CR").Child("fields").Child("field")
+	if got := field.ChildText("description"); got != "Enable" {
+		t.Errorf("EN description = %q, want %q", got, "Enable")
+	}
+}
+
+func TestApplyPatchAddFieldCreatesFields(t *testing.T) {
+	device := readTestSVD(t, patchTestSVD)
+	patch := writeTestFile(t, t.TempDir(), "patch.yaml", `RNG:
+  _add:
+    NEWREG: {addressOffset: "0x8"}
+  NEWREG:
+    _add:
+      READY: {bitOffset: 0, bitWidth: 1}
+`)
+
+	if _, err := applyPatchFile(device, patch); err != nil {
+		t.Fatal(err)
+	}
+	fields := findRegister(device, "RNG", "NEWREG").Child("fields")
+	if fields == nil || fields.Child("field").ChildText("name") != "READY" {
+		t.Errorf("NEWREG fields = %+v, want READY", fields)
+	}
+}
+
+func TestApplyPatchIncludes(t *testing.T) {
+	t.Run("diamond", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "common.yaml", "RNG: {CR: {_add: {SHARED: {bitOffset: 0, bitWidth: 1}}}}\n")
+		writeTestFile(t, dir, "left.yaml", "_include: [common.yaml]\n")
+		writeTestFile(t, dir, "right.yaml", "_include: [./common.yaml]\n")
+		patch := writeTestFile(t, dir, "patch.yaml", "_include: [left.yaml, right.yaml]\n")
+
+		device := readTestSVD(t, patchTestSVD)
+		warnings, err := applyPatchFile(device, patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) != 0 {
+			t.Errorf("warnings = %q, want none", warnings)
+		}
+		shared := 0
+		for _, field := range findRegister(device, "RNG", "CR").Child("fields").ChildrenNamed("field") {
+			if field.ChildText("name") == "SHARED" {
+				shared++
+			}
+		}
+		if shared != 1 {
+			t.Errorf("common.yaml added SHARED %d times, want 1", shared)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "a.yaml", "_include: [b.yaml]\n")
+		writeTestFile(t, dir, "b.yaml", "_include: [a.yaml]\n")
+
+		_, err := applyPatchFile(readTestSVD(t, patchTestSVD), filepath.Join(dir, "a.yaml"))
+		if err == nil || !strings.Contains(err.Error(), "a.yaml includes itself") {
+			t.Errorf("err = %v, want an include cycle error", err)
+		}
+	})
+}
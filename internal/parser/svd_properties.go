@@ -0,0 +1,79 @@
+package parser
+
+// defaultRegisterSize is the register and address width in bits assumed when the SVD declares none.
+const defaultRegisterSize = 32
+
+// registerPropertyNames are the CMSIS-SVD register properties that cascade from the
+// device through peripherals and clusters down to registers.
+var registerPropertyNames = []string{"size", "access", "protection", "resetValue", "resetMask"}
+
+// fieldPropertyNames are the register properties a field can override.
+var fieldPropertyNames = []string{"access"}
+
+// propertyColumns maps each property to its effective value and source columns.
+var propertyColumns = map[string][2]string{
+	"size":       {"_effective_size", "_size_source"},
+	"access":     {"_effective_access", "_access_source"},
+	"protection": {"_effective_protection", "_protection_source"},
+	"resetValue": {"_effective_reset_value", "_reset_value_source"},
+	"resetMask":  {"_effective_reset_mask", "_reset_mask_source"},
+}
+
+// inheritedValue is an effective property value and the level it was defined on.
+type inheritedValue struct {
+	value  string
+	source string
+}
+
+// propertySet holds the effective register properties at one level of the hierarchy.
+type propertySet map[string]inheritedValue
+
+// defaultProperties returns the values assumed when no level defines a property.
+func defaultProperties() propertySet {
+	return propertySet{
+		"size":       {value: "32", source: "default"},
+		"access":     {value: "read-write", source: "default"},
+		"resetValue": {value: "0x0", source: "default"},
+		"resetMask":  {value: "0xFFFFFFFF", source: "default"},
+	}
+}
+
+// inherit returns a copy of the set with the properties declared on elem applied.
+func (ps propertySet) inherit(elem *Element, level string) propertySet {
+	result := make(propertySet, len(registerPropertyNames))
+	for name, value := range ps {
+		result[name] = value
+	}
+	for _, name := range registerPropertyNames {
+		if text := elem.ChildText(name); text != "" {
+			result[name] = inheritedValue{value: text, source: level}
+		}
+	}
+	// An undeclared resetMask covers every bit of the effective size, not just 32.
+	if result["resetMask"].source == "default" {
+		size := result.size()
+		result["resetMask"] = inheritedValue{value: FormatHexWidth(bitMask(0, size), size), source: "default"}
+	}
+	return result
+}
+
+// apply writes the effective value and source columns of the named properties into row.
+func (ps propertySet) apply(row map[string]string, names []string) {
+	for _, name := range names {
+		value, ok := ps[name]
+		if !ok {
+			continue
+		}
+		columns := propertyColumns[name]
+		row[columns[0]] = value.value
+		row[columns[1]] = value.source
+	}
+}
+
+// size returns the effective register size in bits.
+func (ps propertySet) size() uint64 {
+	if size, err := ParseSVDInt(ps["size"].value); err == nil && size > 0 {
+		return size
+	}
+	return defaultRegisterSize
+}
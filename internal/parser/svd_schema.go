@@ -0,0 +1,250 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// requiredElements lists the children CMSIS-SVD 1.1/1.3 requires on each element.
+// Derived elements may inherit them and are only checked for requiredOnDerived.
+var requiredElements = map[string][]string{
+	"device":          {"name", "version", "description", "addressUnitBits", "width", "peripherals"},
+	"cpu":             {"name", "revision", "endian", "mpuPresent", "fpuPresent", "nvicPrioBits", "vendorSystickConfig"},
+	"peripheral":      {"name", "baseAddress"},
+	"addressBlock":    {"offset", "size", "usage"},
+	"interrupt":       {"name", "value"},
+	"cluster":         {"name", "description", "addressOffset"},
+	"register":        {"name", "addressOffset"},
+	"field":           {"name"},
+	"enumeratedValue": {"name"},
+}
+
+// requiredOnDerived are the required children a derived element must still declare.
+var requiredOnDerived = map[string]bool{
+	"name":          true,
+	"baseAddress":   true,
+	"addressOffset": true,
+}
+
+// legalValues lists the enumerated tokens allowed for SVD elements, keyed by
+// element name or by parent/element where the meaning depends on the parent.
+var legalValues = map[string][]string{
+	"access":                    {"read-only", "write-only", "read-write", "writeOnce", "read-writeOnce"},
+	"modifiedWriteValues":       {"oneToClear", "oneToSet", "oneToToggle", "zeroToClear", "zeroToSet", "zeroToToggle", "clear", "set", "modify"},
+	"readAction":                {"clear", "set", "modify", "modifyExternal"},
+	"endian":                    {"little", "big", "selectable", "other"},
+	"protection":                {"s", "n", "p"},
+	"addressBlock/usage":        {"registers", "buffer", "reserved"},
+	"enumeratedValues/usage":    {"read", "write", "read-write"},
+	"mpuPresent":                {"true", "false", "1", "0"},
+	"fpuPresent":                {"true", "false", "1", "0"},
+	"vendorSystickConfig":       {"true", "false", "1", "0"},
+	"enumeratedValue/isDefault": {"true", "false", "1", "0"},
+}
+
+// numericElements are the elements of type scaledNonNegativeInteger.
+var numericElements = map[string]bool{
+	"addressUnitBits":     true,
+	"width":               true,
+	"size":                true,
+	"resetValue":          true,
+	"resetMask":           true,
+	"baseAddress":         true,
+	"offset":              true,
+	"addressOffset":       true,
+	"dim":                 true,
+	"dimIncrement":        true,
+	"bitOffset":           true,
+	"bitWidth":            true,
+	"lsb":                 true,
+	"msb":                 true,
+	"nvicPrioBits":        true,
+	"deviceNumInterrupts": true,
+	"sauNumRegions":       true,
+}
+
+// IsNumericElement reports whether an SVD element holds a scaledNonNegativeInteger.
+func IsNumericElement(name string) bool {
+	return numericElements[name]
+}
+
+// schemaChecker checks an unresolved SVD tree against the CMSIS-SVD schema rules.
+type schemaChecker struct {
+	resolver *deriveResolver
+	issues   []Issue
+}
+
+// Validate checks an SVD file against the CMSIS-SVD 1.1/1.3 schema rules, then
+// resolves derivedFrom references and dim arrays and checks the register map layout.
+func (p *SVDParser) Validate(filename string) ([]Issue, error) {
+	device, err := ReadSVDTree(filename, p.bufferSize)
+	if err != nil {
+		return nil, err
+	}
+	if device.Name != "device" {
+		return nil, fmt.Errorf("not a CMSIS-SVD file: root element is <%s>", device.Name)
+	}
+
+	issues := checkSchema(device)
+
+	resolveDerivedFrom(device)
+	if !p.options.KeepDimArrays {
+		expandDimArrays(device)
+	}
+	return append(issues, validateLayout(device)...), nil
+}
+
+// checkSchema returns the schema issues of an SVD tree before derivedFrom resolution.
+func checkSchema(device *Element) []Issue {
+	c := &schemaChecker{
+		resolver: &deriveResolver{device: device, parents: make(map[*Element]*Element)},
+	}
+	c.resolver.indexParents(device)
+
+	switch version := device.Attr("schemaVersion"); {
+	case version == "":
+		c.add(SeverityError, "required-element", "device", "missing schemaVersion attribute")
+	case version != "1.0" && version != "1.1" && !strings.HasPrefix(version, "1.2") && !strings.HasPrefix(version, "1.3"):
+		c.add(SeverityWarning, "schema-version", "device", "unsupported schemaVersion %q", version)
+	}
+
+	c.check(device, "device")
+	return c.issues
+}
+
+func (c *schemaChecker) add(severity, check, path, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{
+		Severity: severity,
+		Check:    check,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// check checks one element and its subtree. Paths follow element names from the
+// peripheral down (e.g. "GPIOA.MODER.MODER5"); unnamed elements add their tag
+// and vendorExtensions are not checked.
+func (c *schemaChecker) check(elem *Element, path string) {
+	derived := elem.Attr("derivedFrom") != ""
+	for _, name := range requiredElements[elem.Name] {
+		if elem.Child(name) == nil && (!derived || requiredOnDerived[name]) {
+			c.add(SeverityError, "required-element", path, "<%s> is missing <%s>", elem.Name, name)
+		}
+	}
+	if derived {
+		c.checkDerivedFrom(elem, path)
+	}
+
+	switch elem.Name {
+	case "field":
+		if !derived && elem.Child("bitOffset") == nil && elem.Child("lsb") == nil && elem.Child("bitRange") == nil {
+			c.add(SeverityError, "required-element", path, "<field> has no bitOffset, lsb/msb or bitRange")
+		}
+	case "enumeratedValues":
+		if !derived && len(elem.ChildrenNamed("enumeratedValue")) == 0 {
+			c.add(SeverityError, "required-element", path, "<enumeratedValues> has no <enumeratedValue>")
+		}
+	case "enumeratedValue":
+		if elem.Child("value") == nil && elem.Child("isDefault") == nil {
+			c.add(SeverityError, "required-element", path, "<enumeratedValue> has neither <value> nor <isDefault>")
+		}
+	}
+	if elem.Child("dim") != nil && elem.Child("dimIncrement") == nil {
+		c.add(SeverityError, "required-element", path, "<%s> declares <dim> without <dimIncrement>", elem.Name)
+	}
+
+	for _, child := range elem.Children {
+		if child.IsLeaf() {
+			c.checkValue(elem, child, path)
+			continue
+		}
+
+		name := child.ChildText("name")
+		childPath := path
+		switch {
+		case child.Name == "vendorExtensions":
+			continue
+		case child.Name == "peripherals" || child.Name == "registers" || child.Name == "fields":
+		case name != "" && elem.Name == "peripherals":
+			childPath = name
+		case name != "" && child.Name != "cpu":
+			childPath = path + "." + name
+		default:
+			childPath = path + "." + child.Name
+		}
+		c.check(child, childPath)
+	}
+}
+
+// checkValue checks the format or legal token set of a leaf element. Numbers, bit
+// ranges and interrupt values are checked with the converter's own parsers.
+func (c *schemaChecker) checkValue(parent, leaf *Element, path string) {
+	value := leaf.Text
+	if value == "" {
+		return
+	}
+
+	if legal, ok := legalValues[parent.Name+"/"+leaf.Name]; ok {
+		c.checkToken(legal, leaf, path)
+		return
+	}
+	if legal, ok := legalValues[leaf.Name]; ok {
+		c.checkToken(legal, leaf, path)
+		return
+	}
+
+	switch {
+	case parent.Name == "enumeratedValue" && leaf.Name == "value":
+		if _, _, err := ParseSVDPattern(value); err != nil {
+			c.add(SeverityError, "number-format", path, "<value> %q is not a valid enumerated value", value)
+		}
+	case parent.Name == "interrupt" && leaf.Name == "value":
+		if _, err := ParseInterruptValue(value); err != nil {
+			c.add(SeverityError, "number-format", path, "<value> %q is not an integer", value)
+		}
+	case leaf.Name == "bitRange":
+		if _, _, err := parseBitRange(value); err != nil {
+			c.add(SeverityError, "number-format", path, "<bitRange> %q is not of the form [msb:lsb]", value)
+		}
+	case numericElements[leaf.Name]:
+		if _, err := ParseSVDInt(value); err != nil {
+			c.add(SeverityError, "number-format", path, "<%s> %q is not a scaledNonNegativeInteger", leaf.Name, value)
+		}
+	}
+}
+
+func (c *schemaChecker) checkToken(legal []string, leaf *Element, path string) {
+	for _, token := range legal {
+		if leaf.Text == token {
+			return
+		}
+	}
+	c.add(SeverityError, "illegal-value", path, "<%s> %q is not one of %s", leaf.Name, leaf.Text, strings.Join(legal, ", "))
+}
+
+// checkDerivedFrom reports derivedFrom references on elements that do not accept
+// them, references that cannot be found and circular reference chains.
+func (c *schemaChecker) checkDerivedFrom(elem *Element, path string) {
+	ref := elem.Attr("derivedFrom")
+	if !derivableElements[elem.Name] {
+		c.add(SeverityError, "derived-from", path, "<%s> does not accept derivedFrom", elem.Name)
+		return
+	}
+
+	seen := map[*Element]bool{elem: true}
+	for current := elem; current.Attr("derivedFrom") != ""; {
+		base := c.resolver.findBase(current, current.Attr("derivedFrom"))
+		if base == nil {
+			if current == elem {
+				c.add(SeverityError, "derived-from", path, "derivedFrom %q not found", ref)
+			}
+			return
+		}
+		if seen[base] {
+			c.add(SeverityError, "derived-from", path, "derivedFrom %q is part of a circular reference", ref)
+			return
+		}
+		seen[base] = true
+		current = base
+	}
+}
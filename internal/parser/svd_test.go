@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldResetValue(t *testing.T) {
+	const stateEnum = `<enumeratedValues>
+		<enumeratedValue><name>IDLE</name><value>0</value></enumeratedValue>
+		<enumeratedValue><name>BUSY</name><value>0b1x</value></enumeratedValue>
+	</enumeratedValues>`
+	tests := []struct {
+		name     string
+		register string
+		field    string
+		want     map[string]string
+	}{
+		{
+			name:     "field bits of the register reset value",
+			register: `<register><resetValue>0x12345678</resetValue></register>`,
+			field:    `<field><bitRange>[11:4]</bitRange></field>`,
+			want:     map[string]string{"_reset_value": "0x67", "_reset_value_dec": "103", "_reset_defined": "yes"},
+		},
+		{
+			name:     "single bit",
+			register: `<register><resetValue>0x80000000</resetValue></register>`,
+			field:    `<field><bitOffset>31</bitOffset></field>`,
+			want:     map[string]string{"_reset_value": "0x1", "_reset_value_dec": "1", "_reset_defined": "yes"},
+		},
+		{
+			name:     "resetMask covering the field",
+			register: `<register><resetValue>0x0000FF00</resetValue><resetMask>0x0000FF00</resetMask></register>`,
+			field:    `<field><lsb>8</lsb><msb>15</msb></field>`,
+			want:     map[string]string{"_reset_value": "0xFF", "_reset_value_dec": "255", "_reset_defined": "yes"},
+		},
+		{
+			name:     "resetMask excluding the field",
+			register: `<register><resetValue>0x0</resetValue><resetMask>0xFFFF0000</resetMask></register>`,
+			field:    `<field><bitOffset>0</bitOffset><bitWidth>4</bitWidth></field>`,
+			want:     map[string]string{"_reset_value": "0x0", "_reset_value_dec": "0", "_reset_defined": "no"},
+		},
+		{
+			name:     "resetMask covering part of the field",
+			register: `<register><resetValue>0x0</resetValue><resetMask>0x0000000C</resetMask></register>`,
+			field:    `<field><bitOffset>0</bitOffset><bitWidth>4</bitWidth></field>`,
+			want:     map[string]string{"_reset_value": "0x0", "_reset_value_dec": "0", "_reset_defined": "partial"},
+		},
+		{
+			name:     "default resetMask covers a 64-bit register",
+			register: `<register><size>64</size><resetValue>0x1234567800000000</resetValue></register>`,
+			field:    `<field><bitRange>[63:32]</bitRange></field>`,
+			want:     map[string]string{"_reset_value": "0x12345678", "_reset_value_dec": "305419896", "_reset_defined": "yes"},
+		},
+		{
+			name:     "enumerated value name",
+			register: `<register><resetValue>0x30</resetValue></register>`,
+			field:    `<field><bitRange>[5:4]</bitRange>` + stateEnum + `</field>`,
+			want:     map[string]string{"_reset_value": "0x3", "_reset_value_dec": "3", "_reset_defined": "yes", "_reset_enum": "BUSY"},
+		},
+		{
+			name:     "no matching enumerated value",
+			register: `<register><resetValue>0x10</resetValue></register>`,
+			field:    `<field><bitRange>[5:4]</bitRange>` + stateEnum + `</field>`,
+			want:     map[string]string{"_reset_value": "0x1", "_reset_value_dec": "1", "_reset_defined": "yes"},
+		},
+		{
+			name:     "isDefault enumerated value",
+			register: `<register><resetValue>0x2</resetValue></register>`,
+			field: `<field><bitRange>[1:0]</bitRange><enumeratedValues>
+				<enumeratedValue><name>OFF</name><value>0</value></enumeratedValue>
+				<enumeratedValue><name>OTHER</name><isDefault>true</isDefault></enumeratedValue>
+			</enumeratedValues></field>`,
+			want: map[string]string{"_reset_value": "0x2", "_reset_value_dec": "2", "_reset_defined": "yes", "_reset_enum": "OTHER"},
+		},
+		{
+			name:     "no resetValue declared",
+			register: `<register><name>CR</name></register>`,
+			field:    `<field><bitRange>[5:4]</bitRange>` + stateEnum + `</field>`,
+			want:     map[string]string{"_reset_defined": "no"},
+		},
+		{
+			name:     "resetMask without resetValue",
+			register: `<register><resetMask>0xFFFFFFFF</resetMask></register>`,
+			field:    `<field><bitOffset>0</bitOffset></field>`,
+			want:     map[string]string{"_reset_defined": "no"},
+		},
+		{
+			name:     "invalid bit position",
+			register: `<register><resetValue>0x1</resetValue></register>`,
+			field:    `<field><bitRange>[0:3]</bitRange></field>`,
+			want:     map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			properties := defaultProperties().inherit(readTestSVD(t, tt.register), "register")
+			row := make(map[string]string)
+			fieldResetValue(readTestSVD(t, tt.field), row, properties)
+			if !reflect.DeepEqual(row, tt.want) {
+				t.Errorf("row = %v, want %v", row, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldResetValueInheritsResetValue(t *testing.T) {
+	device := readTestSVD(t, `<device><resetValue>0xA5</resetValue><resetMask>0xF0</resetMask></device>`)
+	properties := defaultProperties().inherit(device, "device").inherit(readTestSVD(t, `<register/>`), "register")
+	row := make(map[string]string)
+	fieldResetValue(readTestSVD(t, `<field><bitRange>[7:0]</bitRange></field>`), row, properties)
+	want := map[string]string{"_reset_value": "0xA5", "_reset_value_dec": "165", "_reset_defined": "partial"}
+	if !reflect.DeepEqual(row, want) {
+		t.Errorf("row = %v, want %v", row, want)
+	}
+}
+
+func TestDefaultResetMaskFollowsSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels []string
+		want   string
+	}{
+		{name: "default size", levels: []string{`<register/>`}, want: "0xFFFFFFFF"},
+		{name: "8-bit register", levels: []string{`<register><size>8</size></register>`}, want: "0xFF"},
+		{name: "64-bit register", levels: []string{`<register><size>64</size></register>`}, want: "0xFFFFFFFFFFFFFFFF"},
+		{
+			name:   "size inherited from the peripheral",
+			levels: []string{`<peripheral><size>16</size></peripheral>`, `<register/>`},
+			want:   "0xFFFF",
+		},
+		{
+			name:   "declared resetMask is kept",
+			levels: []string{`<peripheral><resetMask>0xFF</resetMask></peripheral>`, `<register><size>64</size></register>`},
+			want:   "0xFF",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			properties := defaultProperties()
+			for _, level := range tt.levels {
+				elem := readTestSVD(t, level)
+				properties = properties.inherit(elem, elem.Name)
+			}
+			if got := properties["resetMask"].value; got != tt.want {
+				t.Errorf("resetMask = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
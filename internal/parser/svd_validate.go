@@ -0,0 +1,227 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Issue severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem found while checking an SVD file.
+type Issue struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+}
+
+// Row returns the issue as an Issues sheet row.
+func (i Issue) Row() map[string]string {
+	return map[string]string{
+		"severity": i.Severity,
+		"check":    i.Check,
+		"path":     i.Path,
+		"message":  i.Message,
+	}
+}
+
+// layoutItem is a register or field span: byte offsets within the peripheral for
+// registers, bit positions within the register for fields. end is inclusive.
+type layoutItem struct {
+	name      string
+	start     uint64
+	end       uint64
+	alternate bool
+}
+
+// layoutValidator checks the register map of a resolved SVD tree: overlapping and
+// out-of-range fields, overlapping registers, registers outside the address blocks
+// and duplicate names.
+type layoutValidator struct {
+	issues []Issue
+}
+
+// validateLayout returns the layout issues of a device.
+func validateLayout(device *Element) []Issue {
+	v := &layoutValidator{}
+	properties := defaultProperties().inherit(device, "device")
+
+	peripherals := device.Child("peripherals")
+	if peripherals == nil {
+		return nil
+	}
+
+	v.checkDuplicateNames(peripherals.ChildrenNamed("peripheral"), device.ChildText("name"), "peripheral")
+	for _, peripheral := range peripherals.ChildrenNamed("peripheral") {
+		v.checkPeripheral(peripheral, properties.inherit(peripheral, "peripheral"))
+	}
+
+	return v.issues
+}
+
+func (v *layoutValidator) add(severity, check, path, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{
+		Severity: severity,
+		Check:    check,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *layoutValidator) checkPeripheral(peripheral *Element, properties propertySet) {
+	name := peripheral.ChildText("name")
+
+	var registers []layoutItem
+	if container := peripheral.Child("registers"); container != nil {
+		registers = v.collectRegisters(container, name, 0, properties, false)
+	}
+
+	for i := range registers {
+		for j := i + 1; j < len(registers); j++ {
+			a, b := registers[i], registers[j]
+			if a.alternate || b.alternate || a.end < b.start || b.end < a.start {
+				continue
+			}
+			v.add(SeverityError, "register-overlap", a.name, "offset 0x%X-0x%X overlaps %s at 0x%X-0x%X",
+				a.start, a.end, b.name, b.start, b.end)
+		}
+	}
+
+	var blocks []layoutItem
+	for _, block := range peripheral.ChildrenNamed("addressBlock") {
+		offset, offsetErr := ParseSVDInt(block.ChildText("offset"))
+		size, sizeErr := ParseSVDInt(block.ChildText("size"))
+		if offsetErr == nil && sizeErr == nil && size > 0 {
+			blocks = append(blocks, layoutItem{start: offset, end: offset + size - 1})
+		}
+	}
+	if len(blocks) == 0 {
+		return
+	}
+
+	for _, register := range registers {
+		inside := false
+		for _, block := range blocks {
+			if register.start >= block.start && register.end <= block.end {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			v.add(SeverityWarning, "address-block", register.name, "offset 0x%X-0x%X is outside the peripheral's address blocks",
+				register.start, register.end)
+		}
+	}
+}
+
+// collectRegisters checks the registers of a registers or cluster element and returns
+// their byte spans relative to the peripheral base address. Registers inside an
+// alternate cluster, or declaring alternateRegister or alternateGroup, are marked
+// as alternates that may share addresses.
+func (v *layoutValidator) collectRegisters(container *Element, path string, offset uint64, properties propertySet, alternate bool) []layoutItem {
+	items := registerItems(container)
+	v.checkDuplicateNames(items, path, "register or cluster")
+
+	var registers []layoutItem
+	for _, item := range items {
+		itemPath := path + "." + item.ChildText("name")
+		itemOffset, err := ParseSVDInt(item.ChildText("addressOffset"))
+		if err != nil {
+			continue
+		}
+		itemProperties := properties.inherit(item, item.Name)
+
+		if item.Name == "cluster" {
+			registers = append(registers, v.collectRegisters(item, itemPath, offset+itemOffset, itemProperties,
+				alternate || item.ChildText("alternateCluster") != "")...)
+			continue
+		}
+
+		size := itemProperties.size()
+		bytes := (size + 7) / 8
+		start := offset + itemOffset
+		registers = append(registers, layoutItem{
+			name:      itemPath,
+			start:     start,
+			end:       start + dimSpan(item) + bytes - 1,
+			alternate: alternate || item.ChildText("alternateRegister") != "" || item.ChildText("alternateGroup") != "",
+		})
+
+		v.checkFields(item, itemPath, size)
+	}
+
+	return registers
+}
+
+// checkFields checks that the fields of a register fit its size and do not overlap.
+func (v *layoutValidator) checkFields(register *Element, path string, size uint64) {
+	container := register.Child("fields")
+	if container == nil {
+		return
+	}
+
+	fields := container.ChildrenNamed("field")
+	v.checkDuplicateNames(fields, path, "field")
+
+	var spans []layoutItem
+	for _, field := range fields {
+		lsb, width, err := fieldBitPosition(field)
+		if err != nil {
+			continue
+		}
+		span := layoutItem{name: path + "." + field.ChildText("name"), start: lsb, end: lsb + dimSpan(field) + width - 1}
+		if span.end >= size {
+			v.add(SeverityError, "field-range", span.name, "bits [%d:%d] extend past the %d-bit register",
+				span.end, span.start, size)
+		}
+		spans = append(spans, span)
+
+		for _, enumSet := range field.ChildrenNamed("enumeratedValues") {
+			v.checkDuplicateNames(enumSet.ChildrenNamed("enumeratedValue"), span.name, "enumerated value")
+		}
+	}
+
+	for i := range spans {
+		for j := i + 1; j < len(spans); j++ {
+			a, b := spans[i], spans[j]
+			if a.end < b.start || b.end < a.start {
+				continue
+			}
+			v.add(SeverityError, "field-overlap", a.name, "bits [%d:%d] overlap %s [%d:%d]",
+				a.end, a.start, b.name[strings.LastIndex(b.name, ".")+1:], b.end, b.start)
+		}
+	}
+}
+
+// checkDuplicateNames reports elements of one scope that share a name.
+func (v *layoutValidator) checkDuplicateNames(elems []*Element, path, kind string) {
+	seen := make(map[string]bool)
+	for _, elem := range elems {
+		name := elem.ChildText("name")
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			v.add(SeverityError, "duplicate-name", path+"."+name, "duplicate %s name %q", kind, name)
+		}
+		seen[name] = true
+	}
+}
+
+// dimSpan returns how far the last instance of an unexpanded dim array lies past
+// the first one, or 0 for ordinary elements.
+func dimSpan(elem *Element) uint64 {
+	dim, err := ParseSVDInt(elem.ChildText("dim"))
+	if err != nil || dim == 0 {
+		return 0
+	}
+	increment, err := ParseSVDInt(elem.ChildText("dimIncrement"))
+	if err != nil {
+		return 0
+	}
+	return (dim - 1) * increment
+}
@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateLayout(t *testing.T) {
+	tests := []struct {
+		name       string
+		peripheral string
+		want       []string
+	}{
+		{
+			name: "clean layout",
+			peripheral: `<name>P</name>
+				<addressBlock><offset>0</offset><size>0x8</size></addressBlock>
+				<registers>
+				  <register><name>CR</name><addressOffset>0</addressOffset><size>32</size>
+				    <fields>
+				      <field><name>EN</name><bitOffset>0</bitOffset><bitWidth>1</bitWidth></field>
+				      <field><name>MODE</name><bitRange>[3:1]</bitRange></field>
+				    </fields>
+				  </register>
+				  <register><name>SR</name><addressOffset>4</addressOffset><size>32</size></register>
+				</registers>`,
+		},
+		{
+			name: "field overlap",
+			peripheral: `<name>P</name>
+				<registers>
+				  <register><name>CR</name><addressOffset>0</addressOffset><size>32</size>
+				    <fields>
+				      <field><name>A</name><bitRange>[3:0]</bitRange></field>
+				      <field><name>B</name><lsb>3</lsb><msb>4</msb></field>
+				      <field><name>C</name><bitOffset>5</bitOffset></field>
+				    </fields>
+				  </register>
+				</registers>`,
+			want: []string{"error field-overlap P.CR.A: bits [3:0] overlap B [4:3]"},
+		},
+		{
+			name: "field outside register size",
+			peripheral: `<name>P</name>
+				<registers>
+				  <register><name>CR</name><addressOffset>0</addressOffset><size>16</size>
+				    <fields>
+				      <field><name>HI</name><bitOffset>12</bitOffset><bitWidth>8</bitWidth></field>
+				    </fields>
+				  </register>
+				</registers>`,
+			want: []string{"error field-range P.CR.HI: bits [19:12] extend past the 16-bit register"},
+		},
+		{
+			name: "unexpanded field array past the register",
+			peripheral: `<name>P</name>
+				<registers>
+				  <register><name>CR</name><addressOffset>0</addressOffset><size>8</size>
+				    <fields>
+				      <field><name>F%s</name><dim>3</dim><dimIncrement>3</dimIncrement><bitOffset>0</bitOffset><bitWidth>3</bitWidth></field>
+				    </fields>
+				  </register>
+				</registers>`,
+			want: []string{"error field-range P.CR.F%s: bits [8:0] extend past the 8-bit register"},
+		},
+		{
+			name: "register overlap",
+			peripheral: `<name>P</name>
+				<registers>
+				  <register><name>A</name><addressOffset>0</addressOffset><size>32</size></register>
+				  <register><name>B</name><addressOffset>2</addressOffset><size>16</size></register>
+				  <cluster><name>C</name><addressOffset>4</addressOffset>
+				    <register><name>X</name><addressOffset>0</addressOffset><size>32</size></register>
+				  </cluster>
+				  <register><name>D</name><addressOffset>4</addressOffset><size>8</size></register>
+				</registers>`,
+			want: []string{
+				"error register-overlap P.A: offset 0x0-0x3 overlaps P.B at 0x2-0x3",
+				"error register-overlap P.C.X: offset 0x4-0x7 overlaps P.D at 0x4-0x4",
+			},
+		},
+		{
+			name: "alternateRegister and alternateGroup may share addresses",
+			peripheral: `<name>P</name>
+				<registers>
+				  <register><name>CCMR_OUT</name><addressOffset>0</addressOffset><size>32</size></register>
+				  <register><name>CCMR_IN</name><alternateRegister>CCMR_OUT</alternateRegister><addressOffset>0</addressOffset><size>32</size></register>
+				  <register><name>DR</name><alternateGroup>ALT</alternateGroup><addressOffset>0</addressOffset><size>32</size></register>
+				</registers>`,
+		},
+		{
+			name: "alternateCluster registers may share addresses",
+			peripheral: `<name>P</name>
+				<registers>
+				  <register><name>A</name><addressOffset>0</addressOffset><size>32</size></register>
+				  <cluster><name>ALT</name><alternateCluster>MAIN</alternateCluster><addressOffset>0</addressOffset>
+				    <register><name>X</name><addressOffset>0</addressOffset><size>32</size></register>
+				  </cluster>
+				</registers>`,
+		},
+		{
+			name: "register outside its addressBlock",
+			peripheral: `<name>P</name>
+				<addressBlock><offset>0</offset><size>0x8</size></addressBlock>
+				<addressBlock><offset>0x10</offset><size>0x4</size></addressBlock>
+				<registers>
+				  <register><name>A</name><addressOffset>0</addressOffset><size>32</size></register>
+				  <register><name>B</name><addressOffset>6</addressOffset><size>32</size></register>
+				  <register><name>C</name><addressOffset>0x10</addressOffset><size>32</size></register>
+				  <register><name>D</name><addressOffset>0x20</addressOffset><size>8</size></register>
+				</registers>`,
+			want: []string{
+				"warning address-block P.B: offset 0x6-0x9 is outside the peripheral's address blocks",
+				"warning address-block P.D: offset 0x20-0x20 is outside the peripheral's address blocks",
+			},
+		},
+		{
+			name: "unexpanded register array past the addressBlock",
+			peripheral: `<name>P</name>
+				<addressBlock><offset>0</offset><size>0x10</size></addressBlock>
+				<registers>
+				  <register><name>BUF%s</name><dim>5</dim><dimIncrement>4</dimIncrement><addressOffset>0</addressOffset><size>32</size></register>
+				</registers>`,
+			want: []string{"warning address-block P.BUF%s: offset 0x0-0x13 is outside the peripheral's address blocks"},
+		},
+		{
+			name: "duplicate names",
+			peripheral: `<name>P</name>
+				<registers>
+				  <register><name>CR</name><addressOffset>0</addressOffset><size>32</size>
+				    <fields>
+				      <field><name>EN</name><bitOffset>0</bitOffset>
+				        <enumeratedValues>
+				          <enumeratedValue><name>OFF</name><value>0</value></enumeratedValue>
+				          <enumeratedValue><name>OFF</name><value>1</value></enumeratedValue>
+				        </enumeratedValues>
+				      </field>
+				      <field><name>EN</name><bitOffset>1</bitOffset></field>
+				    </fields>
+				  </register>
+				  <cluster><name>CR</name><addressOffset>8</addressOffset>
+				    <register><name>X</name><addressOffset>0</addressOffset><size>32</size></register>
+				  </cluster>
+				</registers>`,
+			want: []string{
+				"error duplicate-name P.CR: duplicate register or cluster name \"CR\"",
+				"error duplicate-name P.CR.EN: duplicate field name \"EN\"",
+				"error duplicate-name P.CR.EN.OFF: duplicate enumerated value name \"OFF\"",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := readTestSVD(t, `<device><name>D</name><peripherals><peripheral>`+tt.peripheral+`</peripheral></peripherals></device>`)
+			var got []string
+			for _, issue := range validateLayout(device) {
+				got = append(got, issue.Severity+" "+issue.Check+" "+issue.Path+": "+issue.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateLayoutDuplicatePeripherals(t *testing.T) {
+	device := readTestSVD(t, `<device><name>D</name><peripherals>
+		<peripheral><name>GPIOA</name></peripheral>
+		<peripheral><name>GPIOA</name></peripheral>
+	</peripherals></device>`)
+	issues := validateLayout(device)
+	if len(issues) != 1 || issues[0].Check != "duplicate-name" || issues[0].Path != "D.GPIOA" {
+		t.Errorf("issues = %+v, want one duplicate-name issue for D.GPIOA", issues)
+	}
+}
+
+func TestDimSpan(t *testing.T) {
+	tests := []struct {
+		elem string
+		want uint64
+	}{
+		{elem: `<register><name>CR</name></register>`, want: 0},
+		{elem: `<register><dim>4</dim><dimIncrement>0x8</dimIncrement></register>`, want: 24},
+		{elem: `<register><dim>1</dim><dimIncrement>4</dimIncrement></register>`, want: 0},
+		{elem: `<register><dim>0</dim><dimIncrement>4</dimIncrement></register>`, want: 0},
+		{elem: `<register><dim>4</dim></register>`, want: 0},
+	}
+	for _, tt := range tests {
+		if got := dimSpan(readTestSVD(t, tt.elem)); got != tt.want {
+			t.Errorf("dimSpan(%s) = %d, want %d", tt.elem, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// svdSchemaLocation attributes reference the CMSIS-SVD schema from the device element.
+var svdSchemaLocation = []xml.Attr{
+	{Name: xml.Name{Local: "xmlns:xs"}, Value: "http://www.w3.org/2001/XMLSchema-instance"},
+	{Name: xml.Name{Local: "xs:noNamespaceSchemaLocation"}, Value: "CMSIS-SVD.xsd"},
+}
+
+// WriteSVDTree writes an SVD element tree as an indented XML file, the counterpart
+// of ReadSVDTree.
+func WriteSVDTree(filename string, device *Element) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+	if _, err := buffered.WriteString(xml.Header); err != nil {
+		return fmt.Errorf("failed to write SVD: %w", err)
+	}
+
+	encoder := xml.NewEncoder(buffered)
+	encoder.Indent("", "  ")
+	if err := encodeElement(encoder, device, svdSchemaLocation); err != nil {
+		return fmt.Errorf("failed to write SVD: %w", err)
+	}
+	if err := encoder.Flush(); err != nil {
+		return fmt.Errorf("failed to write SVD: %w", err)
+	}
+	if _, err := buffered.WriteString("\n"); err != nil {
+		return fmt.Errorf("failed to write SVD: %w", err)
+	}
+
+	return buffered.Flush()
+}
+
+// encodeElement writes an element with its attributes (sorted by name) and children.
+func encodeElement(encoder *xml.Encoder, elem *Element, extraAttrs []xml.Attr) error {
+	start := xml.StartElement{Name: xml.Name{Local: elem.Name}}
+
+	names := make([]string, 0, len(elem.Attrs))
+	for name := range elem.Attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: elem.Attrs[name]})
+	}
+	start.Attr = append(start.Attr, extraAttrs...)
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if elem.IsLeaf() {
+		if err := encoder.EncodeToken(xml.CharData(elem.Text)); err != nil {
+			return err
+		}
+	}
+	for _, c := range elem.Children {
+		if err := encodeElement(encoder, c, nil); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
@@ -0,0 +1,112 @@
+package reader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExcelReader reads the sheets of a workbook back into header-keyed rows.
+type ExcelReader struct {
+	file *excelize.File
+}
+
+// Row is a data row keyed by its sheet's header row, with its 1-based sheet row number.
+// Numeric marks the columns whose value came from a numeric cell rather than text.
+type Row struct {
+	Number  int
+	Values  map[string]string
+	Numeric map[string]bool
+}
+
+func NewExcelReader(filename string) (*ExcelReader, error) {
+	file, err := excelize.OpenFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	return &ExcelReader{file: file}, nil
+}
+
+// HasSheet reports whether the workbook contains the named sheet.
+func (er *ExcelReader) HasSheet(sheetName string) bool {
+	index, err := er.file.GetSheetIndex(sheetName)
+	return err == nil && index >= 0
+}
+
+// ReadSheet streams a sheet and returns its non-empty data rows. The first row is the
+// header; formula cells such as hyperlinks yield their cached value. Row.Numeric is
+// filled for the columns in numericColumns only, as looking up cell types is slow.
+func (er *ExcelReader) ReadSheet(sheetName string, numericColumns map[string]bool) ([]Row, error) {
+	rows, err := er.file.Rows(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheet %s: %w", sheetName, err)
+	}
+	defer rows.Close()
+
+	var headers []string
+	var result []Row
+	number := 0
+	for rows.Next() {
+		number++
+		cells, err := rows.Columns()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %s row %d: %w", sheetName, number, err)
+		}
+
+		if headers == nil {
+			headers = cells
+			continue
+		}
+
+		row := Row{Number: number, Values: make(map[string]string, len(cells))}
+		for i, cell := range cells {
+			if i >= len(headers) || headers[i] == "" || strings.TrimSpace(cell) == "" {
+				continue
+			}
+			value := strings.TrimSpace(cell)
+			row.Values[headers[i]] = value
+			if !numericColumns[headers[i]] {
+				continue
+			}
+			numeric, err := er.isNumericCell(sheetName, i+1, number, value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read sheet %s row %d: %w", sheetName, number, err)
+			}
+			if numeric {
+				if row.Numeric == nil {
+					row.Numeric = make(map[string]bool)
+				}
+				row.Numeric[headers[i]] = true
+			}
+		}
+		if len(row.Values) > 0 {
+			result = append(result, row)
+		}
+	}
+
+	return result, rows.Error()
+}
+
+// isNumericCell reports whether the cell holding value is stored as a number. Only
+// values that read back as plain digits can come from one, so other cells are not looked up.
+func (er *ExcelReader) isNumericCell(sheetName string, column, row int, value string) (bool, error) {
+	if strings.Trim(value, "0123456789") != "" {
+		return false, nil
+	}
+	cell, err := excelize.CoordinatesToCellName(column, row)
+	if err != nil {
+		return false, err
+	}
+	cellType, err := er.file.GetCellType(sheetName, cell)
+	if err != nil {
+		return false, err
+	}
+	// Numbers are stored without a type attribute or with t="n".
+	return cellType == excelize.CellTypeUnset || cellType == excelize.CellTypeNumber, nil
+}
+
+// Close releases the workbook.
+func (er *ExcelReader) Close() error {
+	return er.file.Close()
+}
@@ -0,0 +1,58 @@
+package reader
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadSheetNumericCells(t *testing.T) {
+	file := excelize.NewFile()
+	cells := map[string]interface{}{
+		"A1": "name", "B1": "addressOffset", "C1": "resetValue", "D1": "size",
+		"A2": "CR", "B2": 16, "C2": "0x10", "D2": 32,
+		"A3": "SR", "B3": "16", "C3": "00000010", "D3": "32",
+	}
+	for cell, value := range cells {
+		var err error
+		if text, ok := value.(string); ok {
+			err = file.SetCellStr("Sheet1", cell, text)
+		} else {
+			err = file.SetCellValue("Sheet1", cell, value)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	filename := filepath.Join(t.TempDir(), "test.xlsx")
+	if err := file.SaveAs(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	er, err := NewExcelReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer er.Close()
+	rows, err := er.ReadSheet("Sheet1", map[string]bool{"addressOffset": true, "resetValue": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Row{
+		{
+			Number:  2,
+			Values:  map[string]string{"name": "CR", "addressOffset": "16", "resetValue": "0x10", "size": "32"},
+			Numeric: map[string]bool{"addressOffset": true},
+		},
+		{
+			Number: 3,
+			Values: map[string]string{"name": "SR", "addressOffset": "16", "resetValue": "00000010", "size": "32"},
+		},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+}
@@ -0,0 +1,617 @@
+package writer
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CHeaderModel holds the SVD elements a C device header is generated from, with
+// their numbers already parsed.
+type CHeaderModel struct {
+	// Device maps device properties (name, version, cpu.name, ...) to their values.
+	Device      map[string]string
+	Peripherals []HeaderPeripheral
+	Clusters    []HeaderElement
+	Registers   []HeaderElement
+	Fields      []HeaderField
+	Interrupts  []HeaderInterrupt
+}
+
+// HeaderPeripheral is a peripheral of the header model.
+type HeaderPeripheral struct {
+	ID               string
+	Name             string
+	Description      string
+	HeaderStructName string
+	DerivedFrom      string
+	// DimArray is the dim array name of an expanded instance, e.g. "TIM%s".
+	DimArray       string
+	BaseAddress    uint64
+	HasBaseAddress bool
+}
+
+// HeaderElement is a register or cluster of the header model.
+type HeaderElement struct {
+	ID           string
+	PeripheralID string
+	// ClusterID is the enclosing cluster; empty at peripheral level.
+	ClusterID        string
+	Name             string
+	Description      string
+	HeaderStructName string
+	Access           string
+	DimArray         string
+	Offset           uint64
+	// Size is the effective register size in bits; 0 for clusters.
+	Size uint64
+	// Dim and DimIncrement describe a dim array kept as one element; Dim is 0 otherwise.
+	Dim          uint64
+	DimIncrement uint64
+}
+
+// HeaderField is a field of the header model.
+type HeaderField struct {
+	RegisterID string
+	Name       string
+	Offset     uint64
+	Width      uint64
+}
+
+// HeaderInterrupt is a device interrupt of the header model.
+type HeaderInterrupt struct {
+	Name        string
+	Description string
+	Value       int64
+}
+
+// coreExceptions are the Cortex-M exception numbers listed before the device interrupts.
+var coreExceptions = []struct {
+	name   string
+	number int
+	desc   string
+}{
+	{"NonMaskableInt", -14, "Non Maskable Interrupt"},
+	{"HardFault", -13, "Hard Fault Interrupt"},
+	{"MemoryManagement", -12, "Memory Management Interrupt"},
+	{"BusFault", -11, "Bus Fault Interrupt"},
+	{"UsageFault", -10, "Usage Fault Interrupt"},
+	{"SVCall", -5, "SV Call Interrupt"},
+	{"DebugMonitor", -4, "Debug Monitor Interrupt"},
+	{"PendSV", -2, "Pend SV Interrupt"},
+	{"SysTick", -1, "System Tick Interrupt"},
+}
+
+var nonIdentifierChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// structMember is one member of a generated register struct: a register, a cluster
+// struct or an array of either.
+type structMember struct {
+	name    string
+	ctype   string
+	offset  uint64
+	size    uint64
+	count   uint64
+	comment string
+}
+
+func (m structMember) end() uint64 {
+	return m.offset + m.size*m.count
+}
+
+// headerGenerator writes a CMSIS-style device header.
+type headerGenerator struct {
+	model   CHeaderModel
+	out     strings.Builder
+	device  map[string]string
+	typedef map[string]string // peripheral name -> struct type name
+
+	clusters       map[string]HeaderElement
+	fields         map[string][]HeaderField
+	macros         map[string]bool
+	clusterTypes   map[string]uint64
+	reservedNumber int
+}
+
+// WriteCHeader writes a C header with the interrupt numbers, register structs,
+// peripheral base addresses and instances, and _Pos/_Msk macros for every field.
+func WriteCHeader(filename string, model CHeaderModel) error {
+	g := &headerGenerator{
+		model:        model,
+		device:       model.Device,
+		typedef:      make(map[string]string),
+		clusters:     make(map[string]HeaderElement),
+		fields:       make(map[string][]HeaderField),
+		macros:       make(map[string]bool),
+		clusterTypes: make(map[string]uint64),
+	}
+	if g.device == nil {
+		g.device = make(map[string]string)
+	}
+	for _, cluster := range model.Clusters {
+		g.clusters[cluster.ID] = cluster
+	}
+	for _, field := range model.Fields {
+		g.fields[field.RegisterID] = append(g.fields[field.RegisterID], field)
+	}
+
+	g.generate()
+
+	if err := os.WriteFile(filename, []byte(g.out.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	return nil
+}
+
+func (g *headerGenerator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.out, format, args...)
+}
+
+func (g *headerGenerator) generate() {
+	name := identifier(g.device["name"])
+	if name == "" {
+		name = "DEVICE"
+	}
+	guard := strings.ToUpper(name) + "_H"
+
+	g.printf("/*\n * %s device header generated from CMSIS-SVD", name)
+	if version := g.device["version"]; version != "" {
+		g.printf(" version %s", version)
+	}
+	g.printf(".\n */\n\n")
+	g.printf("#ifndef %s\n#define %s\n\n", guard, guard)
+	g.printf("#ifdef __cplusplus\nextern \"C\" {\n#endif\n\n")
+
+	g.writeInterrupts()
+	g.writeCore()
+	g.writeStructs()
+	g.writeMemoryMap()
+	g.writeFieldMacros()
+
+	g.printf("#ifdef __cplusplus\n}\n#endif\n\n")
+	g.printf("#endif /* %s */\n", guard)
+}
+
+func (g *headerGenerator) writeInterrupts() {
+	g.printf("/* Interrupt numbers */\ntypedef enum {\n")
+	for _, exception := range coreExceptions {
+		g.printf("  %-30s = %3d, /*!< %s */\n", exception.name+"_IRQn", exception.number, exception.desc)
+	}
+
+	seen := make(map[int64]bool)
+	for _, interrupt := range g.model.Interrupts {
+		if seen[interrupt.Value] {
+			continue
+		}
+		seen[interrupt.Value] = true
+		g.printf("  %-30s = %3d, /*!< %s */\n", identifier(interrupt.Name)+"_IRQn", interrupt.Value, commentText(interrupt.Description))
+	}
+	g.printf("} IRQn_Type;\n\n")
+}
+
+// writeCore emits the processor configuration macros and includes the CMSIS core
+// header of Cortex-M CPUs. Volatile qualifiers are defined for other CPUs.
+func (g *headerGenerator) writeCore() {
+	cpu := strings.ToUpper(g.device["cpu.name"])
+	if strings.HasPrefix(cpu, "CM") {
+		core := strings.ReplaceAll(cpu, "+", "PLUS")
+		g.printf("/* Processor and core peripheral configuration */\n")
+		if revision := coreRevision(g.device["cpu.revision"]); revision != "" {
+			g.printf("#define __%s_REV %s\n", core, revision)
+		}
+		for _, flag := range []struct{ macro, property string }{
+			{"__MPU_PRESENT", "cpu.mpuPresent"},
+			{"__FPU_PRESENT", "cpu.fpuPresent"},
+			{"__Vendor_SysTickConfig", "cpu.vendorSystickConfig"},
+		} {
+			if value, ok := g.device[flag.property]; ok {
+				g.printf("#define %s %s\n", flag.macro, boolMacro(value))
+			}
+		}
+		if bits := g.device["cpu.nvicPrioBits"]; bits != "" {
+			g.printf("#define __NVIC_PRIO_BITS %sU\n", bits)
+		}
+		g.printf("\n#include \"core_%s.h\"\n\n", strings.ToLower(core))
+		return
+	}
+
+	g.printf("#include <stdint.h>\n\n")
+	g.printf("#ifndef __I\n#define __I volatile const\n#endif\n")
+	g.printf("#ifndef __O\n#define __O volatile\n#endif\n")
+	g.printf("#ifndef __IO\n#define __IO volatile\n#endif\n\n")
+}
+
+// writeStructs emits one register struct per peripheral type. Peripherals derived from
+// another peripheral, expanded from the same dim array or sharing a headerStructName
+// reuse one struct.
+func (g *headerGenerator) writeStructs() {
+	g.printf("/* Peripheral register structures */\n\n")
+
+	byName := make(map[string]bool)
+	for _, peripheral := range g.model.Peripherals {
+		byName[peripheral.Name] = true
+	}
+
+	written := make(map[string]bool)
+	for _, peripheral := range g.model.Peripherals {
+		typeName := identifier(peripheral.Name)
+		switch {
+		case peripheral.HeaderStructName != "":
+			typeName = identifier(peripheral.HeaderStructName)
+		case peripheral.DerivedFrom != "" && byName[peripheral.DerivedFrom]:
+			if base, ok := g.typedef[peripheral.DerivedFrom]; ok {
+				g.typedef[peripheral.Name] = base
+				continue
+			}
+		case peripheral.DimArray != "":
+			typeName = identifier(dimBaseName(peripheral.DimArray))
+		}
+		typeName += "_TypeDef"
+		g.typedef[peripheral.Name] = typeName
+		if written[typeName] {
+			continue
+		}
+		written[typeName] = true
+
+		members := g.members(peripheral.ID, "", typeName)
+		g.writeStruct(typeName, commentText(peripheral.Description), members, 0)
+	}
+}
+
+// members builds the struct members of a peripheral (clusterID "") or cluster,
+// writing the typedefs of nested clusters first.
+func (g *headerGenerator) members(peripheralID, clusterID, typeName string) []structMember {
+	var members []structMember
+
+	var registers []HeaderElement
+	for _, register := range g.model.Registers {
+		if register.PeripheralID == peripheralID && register.ClusterID == clusterID {
+			registers = append(registers, register)
+		}
+	}
+	for _, group := range dimGroups(registers) {
+		first := group[0]
+		size := registerBytes(first)
+		member := structMember{
+			name:    identifier(first.Name),
+			ctype:   qualifier(first.Access) + " " + integerType(size),
+			offset:  first.Offset,
+			size:    size,
+			count:   1,
+			comment: commentText(first.Description),
+		}
+		if len(group) > 1 {
+			member.name = identifier(dimBaseName(first.DimArray))
+			member.count = uint64(len(group))
+		} else if count := keptDim(first, size); count > 0 {
+			member.name = identifier(dimBaseName(first.Name))
+			member.count = count
+		}
+		members = append(members, member)
+	}
+
+	var clusters []HeaderElement
+	for _, cluster := range g.model.Clusters {
+		if cluster.PeripheralID == peripheralID && cluster.ClusterID == clusterID {
+			clusters = append(clusters, cluster)
+		}
+	}
+	for _, group := range dimGroups(clusters) {
+		first := group[0]
+		baseName := dimBaseName(first.Name)
+		if first.DimArray != "" {
+			baseName = dimBaseName(first.DimArray)
+		}
+		count := uint64(len(group))
+		if kept := keptDim(first, 0); kept > 0 {
+			count = kept
+		}
+		clusterType := strings.TrimSuffix(typeName, "_TypeDef") + "_" + identifier(baseName) + "_TypeDef"
+		if first.HeaderStructName != "" {
+			clusterType = identifier(first.HeaderStructName) + "_TypeDef"
+		}
+
+		size, ok := g.clusterTypes[clusterType]
+		if !ok {
+			stride := uint64(0)
+			if len(group) > 1 {
+				stride = group[1].Offset - group[0].Offset
+			} else if count > 1 {
+				stride = first.DimIncrement
+			}
+			size = g.writeStruct(clusterType, commentText(first.Description), g.members(peripheralID, first.ID, clusterType), stride)
+			g.clusterTypes[clusterType] = size
+		}
+
+		member := structMember{
+			name:    identifier(first.Name),
+			ctype:   clusterType,
+			offset:  first.Offset,
+			size:    size,
+			count:   1,
+			comment: commentText(first.Description),
+		}
+		if count > 1 {
+			member.name = identifier(baseName)
+			member.count = count
+		}
+		members = append(members, member)
+	}
+
+	sort.SliceStable(members, func(i, j int) bool { return members[i].offset < members[j].offset })
+	return members
+}
+
+// writeStruct lays out the members by offset with RESERVED padding, wraps members
+// sharing an offset in an anonymous union, pads the struct to padTo bytes and
+// returns its size.
+func (g *headerGenerator) writeStruct(typeName, description string, members []structMember, padTo uint64) uint64 {
+	g.reservedNumber = 0
+	if description == "" {
+		description = typeName
+	}
+	g.printf("/* %s */\ntypedef struct {\n", description)
+
+	cursor := uint64(0)
+	for i := 0; i < len(members); {
+		j := i + 1
+		for j < len(members) && members[j].offset == members[i].offset {
+			j++
+		}
+		group := members[i:j]
+		i = j
+
+		offset := group[0].offset
+		if offset < cursor {
+			for _, m := range group {
+				g.printf("  /* %s at offset 0x%03X overlaps the previous member */\n", m.name, m.offset)
+			}
+			continue
+		}
+		g.writePadding(cursor, offset)
+
+		if len(group) == 1 {
+			g.writeMember(group[0], "  ")
+			cursor = group[0].end()
+			continue
+		}
+		g.printf("  union {\n")
+		for _, m := range group {
+			g.writeMember(m, "    ")
+			if m.end() > cursor {
+				cursor = m.end()
+			}
+		}
+		g.printf("  };\n")
+	}
+
+	if padTo > cursor {
+		g.writePadding(cursor, padTo)
+		cursor = padTo
+	}
+	g.printf("} %s;\n\n", typeName)
+	return cursor
+}
+
+func (g *headerGenerator) writeMember(m structMember, indent string) {
+	declaration := m.ctype + " " + m.name
+	if m.count > 1 {
+		declaration += fmt.Sprintf("[%d]", m.count)
+	}
+	comment := strings.TrimSpace(fmt.Sprintf("Offset: 0x%03X %s", m.offset, m.comment))
+	g.printf("%s%-40s /*!< %s */\n", indent, declaration+";", comment)
+}
+
+func (g *headerGenerator) writePadding(from, to uint64) {
+	if to <= from {
+		return
+	}
+	gap := to - from
+	if gap%4 == 0 && from%4 == 0 {
+		g.printf("  uint32_t RESERVED%d[%d];\n", g.reservedNumber, gap/4)
+	} else {
+		g.printf("  uint8_t RESERVED%d[%d];\n", g.reservedNumber, gap)
+	}
+	g.reservedNumber++
+}
+
+func (g *headerGenerator) writeMemoryMap() {
+	g.printf("/* Peripheral memory map */\n")
+	for _, peripheral := range g.model.Peripherals {
+		if peripheral.HasBaseAddress {
+			g.printf("#define %-30s (0x%08XUL)\n", identifier(peripheral.Name)+"_BASE", peripheral.BaseAddress)
+		}
+	}
+
+	g.printf("\n/* Peripheral declarations */\n")
+	for _, peripheral := range g.model.Peripherals {
+		if !peripheral.HasBaseAddress {
+			continue
+		}
+		name := identifier(peripheral.Name)
+		g.printf("#define %-30s ((%s *) %s_BASE)\n", name, g.typedef[peripheral.Name], name)
+	}
+	g.printf("\n")
+}
+
+// writeFieldMacros emits _Pos and _Msk macros for the fields of each peripheral type.
+// Macros repeated by derived peripherals and dim instances are written once.
+func (g *headerGenerator) writeFieldMacros() {
+	g.printf("/* Field positions and masks */\n")
+
+	typeOwner := make(map[string]string)
+	typeOf := make(map[string]string)
+	for _, peripheral := range g.model.Peripherals {
+		typeName := g.typedef[peripheral.Name]
+		typeOf[peripheral.ID] = typeName
+		if _, ok := typeOwner[typeName]; !ok {
+			typeOwner[typeName] = peripheral.ID
+		}
+	}
+
+	for _, register := range g.model.Registers {
+		typeName := typeOf[register.PeripheralID]
+		if typeOwner[typeName] != register.PeripheralID {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(typeName, "_TypeDef") + "_" + g.registerMacroName(register)
+		for _, field := range g.fields[register.ID] {
+			if field.Width == 0 {
+				continue
+			}
+
+			macro := prefix + "_" + identifier(dimBaseName(field.Name))
+			if g.macros[macro] {
+				continue
+			}
+			g.macros[macro] = true
+
+			mask := uint64(1)<<field.Width - 1
+			if field.Width >= 64 {
+				mask = ^uint64(0)
+			}
+			g.printf("#define %-40s (%dU)\n", macro+"_Pos", field.Offset)
+			g.printf("#define %-40s (0x%XUL << %s_Pos)\n", macro+"_Msk", mask, macro)
+		}
+	}
+	g.printf("\n")
+}
+
+// registerMacroName joins the names of the enclosing clusters and the register, using
+// dim array base names so every instance maps to the same macros.
+func (g *headerGenerator) registerMacroName(register HeaderElement) string {
+	names := []string{elementBaseName(register)}
+	for id := register.ClusterID; id != ""; {
+		cluster, ok := g.clusters[id]
+		if !ok {
+			break
+		}
+		names = append([]string{elementBaseName(cluster)}, names...)
+		id = cluster.ClusterID
+	}
+	return strings.Join(names, "_")
+}
+
+// dimGroups groups consecutive instances of the same dim array that are laid out
+// contiguously (stride equal to the element size for registers) into one array.
+func dimGroups(elements []HeaderElement) [][]HeaderElement {
+	var groups [][]HeaderElement
+	for _, element := range elements {
+		if n := len(groups); n > 0 && element.DimArray != "" {
+			group := groups[n-1]
+			if group[0].DimArray == element.DimArray && evenlySpaced(group, element) {
+				groups[n-1] = append(group, element)
+				continue
+			}
+		}
+		groups = append(groups, []HeaderElement{element})
+	}
+	return groups
+}
+
+// evenlySpaced reports whether element continues the address stride of group.
+// Register arrays additionally need a stride equal to the register size.
+func evenlySpaced(group []HeaderElement, element HeaderElement) bool {
+	last := group[len(group)-1].Offset
+	if element.Offset <= last {
+		return false
+	}
+	stride := element.Offset - last
+	if len(group) > 1 && (last-group[0].Offset)/uint64(len(group)-1) != stride {
+		return false
+	}
+	if element.Size > 0 {
+		return stride == registerBytes(element)
+	}
+	return true
+}
+
+// keptDim returns the element count of a dim array kept as one element
+// (--keep-dim-arrays) that can be declared as a C array, or 0. Register arrays need a
+// dimIncrement equal to the register size; size 0 skips that check.
+func keptDim(element HeaderElement, size uint64) uint64 {
+	if element.Dim == 0 || !strings.Contains(element.Name, "%s") {
+		return 0
+	}
+	if size > 0 && element.DimIncrement != size {
+		return 0
+	}
+	return element.Dim
+}
+
+// registerBytes returns the size of a register in bytes, 4 when it is unknown.
+func registerBytes(register HeaderElement) uint64 {
+	size := register.Size
+	if size == 0 {
+		size = 32
+	}
+	return (size + 7) / 8
+}
+
+func integerType(bytes uint64) string {
+	switch bytes {
+	case 1:
+		return "uint8_t"
+	case 2:
+		return "uint16_t"
+	case 8:
+		return "uint64_t"
+	default:
+		return "uint32_t"
+	}
+}
+
+func qualifier(access string) string {
+	switch access {
+	case "read-only":
+		return "__I "
+	case "write-only", "writeOnce":
+		return "__O "
+	default:
+		return "__IO"
+	}
+}
+
+// dimBaseName removes the %s placeholder (and its brackets) from a dim array name.
+func dimBaseName(name string) string {
+	name = strings.ReplaceAll(name, "[%s]", "")
+	return strings.ReplaceAll(name, "%s", "")
+}
+
+// elementBaseName returns the dim array base name of an instance, or its own name.
+func elementBaseName(element HeaderElement) string {
+	if element.DimArray != "" {
+		return identifier(dimBaseName(element.DimArray))
+	}
+	return identifier(dimBaseName(element.Name))
+}
+
+// identifier turns an SVD name into a C identifier.
+func identifier(name string) string {
+	return nonIdentifierChars.ReplaceAllString(strings.TrimSpace(name), "_")
+}
+
+// commentText flattens a description into one line that is safe inside a C comment.
+func commentText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "*/", "* /")
+}
+
+// coreRevision converts an SVD cpu revision such as r0p1 into 0x0001.
+func coreRevision(revision string) string {
+	var major, minor int
+	if _, err := fmt.Sscanf(strings.ToLower(revision), "r%dp%d", &major, &minor); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("0x%02X%02XU", major, minor)
+}
+
+func boolMacro(value string) string {
+	if value == "true" || value == "1" {
+		return "1U"
+	}
+	return "0U"
+}
@@ -2,6 +2,8 @@ package writer
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/TomyTang331/Xml2ExcelByGo/internal/config"
 	"github.com/xuri/excelize/v2"
@@ -12,14 +14,41 @@ type ExcelWriter struct {
 	filename      string
 	batchSize     int
 	currentSheets map[string]*SheetWriter
+	linkStyleID   int
 }
 
 type SheetWriter struct {
 	streamWriter *excelize.StreamWriter
 	headers      []string
 	rowIndex     int
-	rowBuffer    []map[string]string
+	rowBuffer    []bufferedRow
 	batchSize    int
+	colWidths    map[int]float64
+	links        map[int]ColumnLink
+
+	// headerRow is written on the first flush, so column widths can still be set after CreateSheet.
+	headerRow     []interface{}
+	headerWritten bool
+}
+
+// ColumnLink turns the cells of a column into HYPERLINK formulas jumping to a row of
+// another sheet. The target row comes from a value of the row being written, so rows
+// can be streamed to both sheets concurrently.
+type ColumnLink struct {
+	// Sheet is the target sheet.
+	Sheet string
+	// IndexKey is the row value holding the 0-based position of the target row among
+	// the data rows of Sheet. Rows without it are written without a link.
+	IndexKey string
+	// Label is the text shown in the cell; empty shows the column's value.
+	Label string
+}
+
+// bufferedRow is a row of cell values waiting to be flushed, with the column spans
+// (1-based, inclusive) to merge within that row.
+type bufferedRow struct {
+	cells  []interface{}
+	merges [][2]int
 }
 
 func NewExcelWriter(filename string, batchSize int) *ExcelWriter {
@@ -65,16 +94,13 @@ func (ew *ExcelWriter) CreateSheet(sheetName string, headers []string) error {
 		}
 	}
 
-	if err := streamWriter.SetRow("A1", headerRow); err != nil {
-		return fmt.Errorf("failed to write header: %w", err)
-	}
-
 	ew.currentSheets[sheetName] = &SheetWriter{
 		streamWriter: streamWriter,
 		headers:      headers,
 		rowIndex:     2,
-		rowBuffer:    make([]map[string]string, 0, ew.batchSize),
+		rowBuffer:    make([]bufferedRow, 0, ew.batchSize),
 		batchSize:    ew.batchSize,
+		headerRow:    headerRow,
 	}
 
 	return nil
@@ -82,12 +108,52 @@ func (ew *ExcelWriter) CreateSheet(sheetName string, headers []string) error {
 
 // WriteRow appends a row to the buffer and flushes if full.
 func (ew *ExcelWriter) WriteRow(sheetName string, data map[string]string) error {
+	return ew.WriteTypedRow(sheetName, data, nil)
+}
+
+// WriteTypedRow is WriteRow with prepared cell values (numbers or excelize.Cell with a
+// style) replacing the text of the columns they are given for.
+func (ew *ExcelWriter) WriteTypedRow(sheetName string, data map[string]string, cells map[string]interface{}) error {
 	sheet, ok := ew.currentSheets[sheetName]
 	if !ok {
 		return fmt.Errorf("sheet not found: %s", sheetName)
 	}
 
-	sheet.rowBuffer = append(sheet.rowBuffer, data)
+	row := make([]interface{}, len(sheet.headers))
+	for i, header := range sheet.headers {
+		if link, ok := sheet.links[i]; ok {
+			if cell, ok := ew.linkCell(link, data[header], data[link.IndexKey]); ok {
+				row[i] = cell
+				continue
+			}
+		}
+
+		if cell, exists := cells[header]; exists {
+			row[i] = cell
+		} else if val, exists := data[header]; exists {
+			row[i] = val
+		} else {
+			row[i] = ""
+		}
+	}
+
+	return ew.bufferRow(sheetName, sheet, bufferedRow{cells: row})
+}
+
+// WriteCells appends a row of prepared cell values (strings, numbers or excelize.Cell
+// with a style) and merges the given 1-based column spans within that row.
+func (ew *ExcelWriter) WriteCells(sheetName string, cells []interface{}, merges [][2]int) error {
+	sheet, ok := ew.currentSheets[sheetName]
+	if !ok {
+		return fmt.Errorf("sheet not found: %s", sheetName)
+	}
+
+	return ew.bufferRow(sheetName, sheet, bufferedRow{cells: cells, merges: merges})
+}
+
+// bufferRow appends a row to the sheet buffer and flushes if full.
+func (ew *ExcelWriter) bufferRow(sheetName string, sheet *SheetWriter, row bufferedRow) error {
+	sheet.rowBuffer = append(sheet.rowBuffer, row)
 
 	if len(sheet.rowBuffer) >= sheet.batchSize {
 		return ew.flushSheet(sheetName)
@@ -96,6 +162,161 @@ func (ew *ExcelWriter) WriteRow(sheetName string, data map[string]string) error
 	return nil
 }
 
+// NewFillStyle registers a centered, bordered cell style with the given background color.
+// Styles must be created before rows are written from concurrent goroutines.
+func (ew *ExcelWriter) NewFillStyle(color string) (int, error) {
+	border := []excelize.Border{
+		{Type: "left", Color: config.BorderColor, Style: 1},
+		{Type: "right", Color: config.BorderColor, Style: 1},
+		{Type: "top", Color: config.BorderColor, Style: 1},
+		{Type: "bottom", Color: config.BorderColor, Style: 1},
+	}
+	return ew.file.NewStyle(&excelize.Style{
+		Border: border,
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{color},
+			Pattern: 1,
+		},
+		Alignment: &excelize.Alignment{
+			Horizontal: "center",
+			Vertical:   "center",
+			WrapText:   true,
+		},
+	})
+}
+
+// NewHighlightStyle registers a cell style that only sets a background color.
+// Styles must be created before rows are written from concurrent goroutines.
+func (ew *ExcelWriter) NewHighlightStyle(color string) (int, error) {
+	return ew.file.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{color},
+			Pattern: 1,
+		},
+	})
+}
+
+// NewNumberStyle creates a style for numeric cells. Number format 1 ("0") keeps
+// large addresses out of scientific notation.
+func (ew *ExcelWriter) NewNumberStyle() (int, error) {
+	return ew.file.NewStyle(&excelize.Style{NumFmt: 1})
+}
+
+// SetColumnLinks makes the given columns of a sheet hyperlinks to rows of other sheets.
+// The linking and target sheets must already be created, and links must be set before
+// rows are written from concurrent goroutines.
+func (ew *ExcelWriter) SetColumnLinks(sheetName string, links map[string]ColumnLink) error {
+	sheet, ok := ew.currentSheets[sheetName]
+	if !ok {
+		return fmt.Errorf("sheet not found: %s", sheetName)
+	}
+
+	if err := ew.ensureLinkStyle(); err != nil {
+		return err
+	}
+
+	if sheet.links == nil {
+		sheet.links = make(map[int]ColumnLink)
+	}
+	for header, link := range links {
+		if _, ok := ew.currentSheets[link.Sheet]; !ok {
+			return fmt.Errorf("sheet not found: %s", link.Sheet)
+		}
+		column := headerIndex(sheet.headers, header)
+		if column < 0 || link.IndexKey == "" {
+			return fmt.Errorf("invalid link %s.%s to %s", sheetName, header, link.Sheet)
+		}
+		sheet.links[column] = link
+	}
+
+	return nil
+}
+
+// ensureLinkStyle creates the hyperlink cell style on first use.
+func (ew *ExcelWriter) ensureLinkStyle() error {
+	if ew.linkStyleID != 0 {
+		return nil
+	}
+
+	styleID, err := ew.file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Color: config.LinkFontColor, Underline: "single"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create link style: %w", err)
+	}
+	ew.linkStyleID = styleID
+	return nil
+}
+
+// SheetLinkCell builds a hyperlink cell jumping to the top of another sheet.
+// Like SetColumnLinks, it must not be called while rows are written concurrently.
+func (ew *ExcelWriter) SheetLinkCell(sheetName, label string) (excelize.Cell, error) {
+	if err := ew.ensureLinkStyle(); err != nil {
+		return excelize.Cell{}, err
+	}
+
+	sheetRef := "'" + strings.ReplaceAll(sheetName, "'", "''") + "'"
+	quotedLabel := `"` + strings.ReplaceAll(label, `"`, `""`) + `"`
+	return excelize.Cell{
+		StyleID: ew.linkStyleID,
+		Formula: fmt.Sprintf(`HYPERLINK("#%s!A1",%s)`, sheetRef, quotedLabel),
+		Value:   label,
+	}, nil
+}
+
+// linkCell builds the hyperlink formula cell jumping to the target row at index.
+// It reports false when the row has no valid index or there is no text to show.
+func (ew *ExcelWriter) linkCell(link ColumnLink, value, index string) (excelize.Cell, bool) {
+	position, err := strconv.Atoi(index)
+	if err != nil || position < 0 {
+		return excelize.Cell{}, false
+	}
+	label := link.Label
+	if label == "" {
+		label = value
+	}
+	if label == "" {
+		return excelize.Cell{}, false
+	}
+
+	quotedLabel := `"` + strings.ReplaceAll(label, `"`, `""`) + `"`
+	sheetRef := "'" + strings.ReplaceAll(link.Sheet, "'", "''") + "'"
+	// Data rows start below the header row
+	return excelize.Cell{
+		StyleID: ew.linkStyleID,
+		Formula: fmt.Sprintf(`HYPERLINK("#%s!A%d",%s)`, sheetRef, position+2, quotedLabel),
+		Value:   label,
+	}, true
+}
+
+// headerIndex returns the 0-based position of header, or -1.
+func headerIndex(headers []string, header string) int {
+	for i, h := range headers {
+		if h == header {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetColumnWidths overrides the default width of individual columns (1-based) of a sheet.
+// It must be called before the sheet's first rows are flushed.
+func (ew *ExcelWriter) SetColumnWidths(sheetName string, widths map[int]float64) error {
+	sheet, ok := ew.currentSheets[sheetName]
+	if !ok {
+		return fmt.Errorf("sheet not found: %s", sheetName)
+	}
+
+	if sheet.headerWritten {
+		return fmt.Errorf("column widths must be set before rows are written: %s", sheetName)
+	}
+
+	sheet.colWidths = widths
+	return nil
+}
+
 // flushSheet writes buffered data to the worksheet.
 func (ew *ExcelWriter) flushSheet(sheetName string) error {
 	sheet, ok := ew.currentSheets[sheetName]
@@ -103,25 +324,30 @@ func (ew *ExcelWriter) flushSheet(sheetName string) error {
 		return fmt.Errorf("sheet not found: %s", sheetName)
 	}
 
+	if !sheet.headerWritten {
+		if err := writeHeader(sheet); err != nil {
+			return err
+		}
+	}
+
 	if len(sheet.rowBuffer) == 0 {
 		return nil
 	}
 
-	for _, rowData := range sheet.rowBuffer {
-		row := make([]interface{}, len(sheet.headers))
-		for i, header := range sheet.headers {
-			if val, exists := rowData[header]; exists {
-				row[i] = val
-			} else {
-				row[i] = ""
-			}
-		}
-
+	for _, row := range sheet.rowBuffer {
 		cellName, _ := excelize.CoordinatesToCellName(1, sheet.rowIndex)
-		if err := sheet.streamWriter.SetRow(cellName, row); err != nil {
+		if err := sheet.streamWriter.SetRow(cellName, row.cells); err != nil {
 			return fmt.Errorf("failed to write row: %w", err)
 		}
 
+		for _, span := range row.merges {
+			topLeft, _ := excelize.CoordinatesToCellName(span[0], sheet.rowIndex)
+			bottomRight, _ := excelize.CoordinatesToCellName(span[1], sheet.rowIndex)
+			if err := sheet.streamWriter.MergeCell(topLeft, bottomRight); err != nil {
+				return fmt.Errorf("failed to merge cells: %w", err)
+			}
+		}
+
 		sheet.rowIndex++
 	}
 
@@ -143,12 +369,6 @@ func (ew *ExcelWriter) Close() error {
 		}
 	}
 
-	for sheetName, sheet := range ew.currentSheets {
-		if err := ew.autoSizeColumns(sheetName, sheet.headers); err != nil {
-			fmt.Printf("Warning: failed to auto-size columns: %v\n", err)
-		}
-	}
-
 	if err := ew.file.SaveAs(ew.filename); err != nil {
 		return fmt.Errorf("failed to save file: %w", err)
 	}
@@ -156,10 +376,31 @@ func (ew *ExcelWriter) Close() error {
 	return nil
 }
 
-func (ew *ExcelWriter) autoSizeColumns(sheetName string, headers []string) error {
-	for i := range headers {
-		colName, _ := excelize.ColumnNumberToName(i + 1)
-		if err := ew.file.SetColWidth(sheetName, colName, colName, config.DefaultColWidth); err != nil {
+// writeHeader sets the column widths and writes the header row. The stream writer
+// only accepts column widths before the first row is written.
+func writeHeader(sheet *SheetWriter) error {
+	if err := autoSizeColumns(sheet); err != nil {
+		fmt.Printf("Warning: failed to auto-size columns: %v\n", err)
+	}
+
+	if err := sheet.streamWriter.SetRow("A1", sheet.headerRow); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	sheet.headerWritten = true
+	return nil
+}
+
+// autoSizeColumns sets every column to the default or custom width. Columns are set
+// from last to first because the stream writer prepends each new column definition.
+func autoSizeColumns(sheet *SheetWriter) error {
+	for col := len(sheet.headers); col >= 1; col-- {
+		width := float64(config.DefaultColWidth)
+		if custom, ok := sheet.colWidths[col]; ok {
+			width = custom
+		}
+
+		if err := sheet.streamWriter.SetColWidth(col, col, width); err != nil {
 			return err
 		}
 	}